@@ -6,12 +6,15 @@ package main
 
 import (
 	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -20,12 +23,27 @@ import (
 
 	"github.com/CloudNativeDataPlane/cndp/lang/go/bindings/cne"
 	cz "github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/colorize"
+	"github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/cniconfig"
 	"github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/etimers"
+	"github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/flowexport"
+	"github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/l2l3resp"
+	"github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/nlwatch"
+	"github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/sampler"
 	tlog "github.com/CloudNativeDataPlane/cndp/lang/go/tools/pkgs/ttylog"
 	tcell "github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 )
 
+// samplingExpireInterval is how often the sampler.Context is swept for idle
+// flows past their TTL.
+const samplingExpireInterval = 5 * time.Second
+
+// arpProbeInterval bounds how often transmitPackets re-probes a destination
+// that is still unresolved in the neighbor cache, so a hot transmit loop
+// does not flood the local segment with ARP requests while waiting for a
+// reply.
+const arpProbeInterval = time.Second
+
 const (
 	samplingLogID   = "SamplingLogID"
 	timerSteps = 2
@@ -42,15 +60,27 @@ type SamplingInfo struct {
 	timers *etimers.EventTimers
 	stats  []*cne.LPortStats
 	redraw bool
-	samplingCtx map[uint32]uint32
+	samplingCtx sampler.Context
+	exporter    *flowexport.Exporter
+	linkWatch   *nlwatch.Watcher
+
+	lportIdentities map[string]l2l3resp.Identity
+	respMu          sync.Mutex
+	responders      []*l2l3resp.Responder
 }
 
 var (
-	SamplingAction string = "FORWARD"
-    SamplingPktsLimit uint32 = 15
 	ConfigFlag string
+	CNIConfigDirFlag string
 	TestFlag   string
 	PttyFlag   string
+	ExportFlag string
+	CollectorFlag string
+	PolicyFlag      string
+	TokenRateFlag   float64
+	TokenBurstFlag  float64
+	ReservoirSizeFlag int
+	FlowTTLFlag     time.Duration
 	twirl      int
 	twirlStr   string = "|/-\\"
 )
@@ -61,10 +91,22 @@ func init() {
 	flag.StringVar(&ConfigFlag, "c", "", "path to configuration file")
 	flag.StringVar(&ConfigFlag, "config", "", "path to configuration file")
 
-	flag.StringVar(&TestFlag, "t", "rx", "run tests - rx|tx|lb|chksum")
-	flag.StringVar(&TestFlag, "test", "rx", "run tests - rx|tx|lb|chksum")
+	flag.StringVar(&CNIConfigDirFlag, "cni-config", "",
+		"path to directory of CNDP JSON snippets dropped by cndp-cni; merged in place of -c")
+
+	flag.StringVar(&TestFlag, "t", "rx", "run tests - rx|tx|lb|chksum|respond")
+	flag.StringVar(&TestFlag, "test", "rx", "run tests - rx|tx|lb|chksum|respond")
 
 	flag.StringVar(&PttyFlag, "ptty", "", "pseudo tty index value or path to /dev/pts/X")
+
+	flag.StringVar(&ExportFlag, "export", "none", "flow record export format - ipfix|sflow|none")
+	flag.StringVar(&CollectorFlag, "collector", "", "flow record collector address, host:port")
+
+	flag.StringVar(&PolicyFlag, "policy", "tokenbucket", "per-flow sampling policy - tokenbucket|reservoir")
+	flag.Float64Var(&TokenRateFlag, "token-rate", 1000, "token bucket refill rate, pkts/sec")
+	flag.Float64Var(&TokenBurstFlag, "token-burst", 1000, "token bucket burst size, pkts")
+	flag.IntVar(&ReservoirSizeFlag, "reservoir-size", 16, "reservoir sample size per flow")
+	flag.DurationVar(&FlowTTLFlag, "flow-ttl", 30*time.Second, "idle TTL before a flow's sampling state is expired")
 }
 
 // collect the stats for each lport and store them in the SamplingInfo structure
@@ -81,27 +123,79 @@ func (f *SamplingInfo) collectStats() {
 }
 
 // update sampling count
-func (f *SamplingInfo) getSamplingAction(pkt *cne.Packet)  string {
-	SamplingAction = "FORWARD"
+func (f *SamplingInfo) getSamplingAction(pkt *cne.Packet) string {
 
 	//(*C.pktmbuf_t)(unsafe.Pointer(pkt))
-	hash := cne.GetHash(pkt) 
+	hash := cne.GetHash(pkt)
     //hash value is set FOR ipv4 or ipv6 PACKETS
-	if hash != 0 {
-		count,found := f.samplingCtx[hash]
-		if !found {
-			f.samplingCtx[hash] = 1
-		} else if count > SamplingPktsLimit {
-			SamplingAction = "DROP"
-		} else {
-			f.samplingCtx[hash] = (count+1)
-		}
-	} else {
-		SamplingAction = "FORWARD"
+	if hash == 0 {
+		return string(sampler.Forward)
+	}
+
+	return string(f.samplingCtx.Sample(hash, pkt.Len(), time.Now()))
+}
+
+// Offsets used to pick the TCP/UDP source and destination ports out of the
+// raw frame: both protocols put a 16-bit source port immediately followed
+// by a 16-bit destination port at the start of their header, so no
+// protocol-specific parsing beyond the IPv4 header length is needed.
+const (
+	ethHeaderLen     = 14
+	ipv4MinHeaderLen = 20
+	protoTCP         = 6
+	protoUDP         = 17
+)
+
+// l4Ports extracts the TCP/UDP source and destination ports from frame,
+// which must start at the Ethernet header, returning ok false for any
+// other protocol or a frame too short to hold one.
+func l4Ports(frame []byte, proto uint8) (srcPort, dstPort uint16, ok bool) {
+	if proto != protoTCP && proto != protoUDP {
+		return 0, 0, false
+	}
+	if len(frame) < ethHeaderLen+ipv4MinHeaderLen {
+		return 0, 0, false
+	}
+
+	ihl := int(frame[ethHeaderLen]&0x0f) * 4
+	l4Off := ethHeaderLen + ihl
+	if len(frame) < l4Off+4 {
+		return 0, 0, false
+	}
+
+	return binary.BigEndian.Uint16(frame[l4Off : l4Off+2]), binary.BigEndian.Uint16(frame[l4Off+2 : l4Off+4]), true
+}
+
+// observeFlow folds an observed packet into the flow-export pipeline, when
+// one is configured. Only IPv4 packets are aggregated today; TCP and UDP
+// packets additionally contribute their ports so two flows between the
+// same hosts on different ports don't collapse into one record.
+func (f *SamplingInfo) observeFlow(pkt *cne.Packet, ingressLPort int) {
+	if f.exporter == nil {
+		return
+	}
+
+	ethHdr := cne.GetEtherHdr(pkt)
+	if ethHdr.EtherType != cne.SwapUint16(cne.EtherTypeIPV4) {
+		return
 	}
-	//fmt.Println("Hash and Action", hash, SamplingAction)
-	return SamplingAction
+
+	ip := cne.GetIPv4(pkt)
+
+	var key flowexport.FlowKey
+	binary.BigEndian.PutUint32(key.SrcIP[:4], ip.SrcAddr)
+	binary.BigEndian.PutUint32(key.DstIP[:4], ip.DstAddr)
+	key.Proto = ip.NextProtoID
+	key.IngressPort = uint16(ingressLPort)
+
+	if srcPort, dstPort, ok := l4Ports(cne.ReadPktData(pkt), ip.NextProtoID); ok {
+		key.SrcPort = srcPort
+		key.DstPort = dstPort
+	}
+
+	f.exporter.Observe(key, pkt.Len(), time.Now())
 }
+
 // display the stats for all lports into a table
 func (f *SamplingInfo) displayStats() {
 
@@ -117,6 +211,12 @@ func (f *SamplingInfo) displayStats() {
 	}
 	row++
 	col = 0
+	for _, t := range []string{"Link State", "   MAC", "   IPv4"} {
+		f.table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("%-12s", t)).SetTextColor(tcell.ColorOrange))
+		f.table.SetCell(row, col+1, tview.NewTableCell(":").SetTextColor(tcell.ColorOrange))
+		row++
+	}
+	row++
 	for _, t := range []string{"Rx Pkts/s", "   TotalPkts", "   MBytes", "   Errors", "   Missed", "   Invalid"} {
 		f.table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("%-12s", t)).SetTextColor(tcell.ColorOrange))
 		f.table.SetCell(row, col+1, tview.NewTableCell(":").SetTextColor(tcell.ColorOrange))
@@ -129,12 +229,21 @@ func (f *SamplingInfo) displayStats() {
 		row++
 	}
     row++
-	for _, t := range []string{"Total Sampling Contexts"} {
+	for _, t := range []string{"Total Sampling Contexts", "   Active Flows", "   Exported", "   Expired", "   Dropped"} {
 		f.table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("%-12s", t)).SetTextColor(tcell.ColorOrange))
 		f.table.SetCell(row, col+1, tview.NewTableCell(":").SetTextColor(tcell.ColorOrange))
 		row++
 	}
-    
+	if TestFlag == "respond" {
+		for _, t := range []string{"ARP Replies", "ICMP Echoes", "ICMPv6 Echoes", "ND Adverts"} {
+			f.table.SetCell(row, col, tview.NewTableCell(fmt.Sprintf("%-12s", t)).SetTextColor(tcell.ColorOrange))
+			f.table.SetCell(row, col+1, tview.NewTableCell(":").SetTextColor(tcell.ColorOrange))
+			row++
+		}
+	}
+
+	ports := f.handle.LPortList()
+
 	prt := message.NewPrinter(language.English)
 	for i, s := range f.stats {
 		row = 1
@@ -143,34 +252,78 @@ func (f *SamplingInfo) displayStats() {
 		if s == nil {
 			continue
 		}
-		f.table.SetCell(row+0, col, tview.NewTableCell(prt.Sprintf("%14v",
+
+		if i < len(ports) {
+			if link, ok := f.linkWatch.Link(ports[i].Name()); ok {
+				state := "DOWN"
+				if link.OperUp {
+					state = "UP"
+				}
+				var ipv4 string
+				if len(link.IPv4) > 0 {
+					ipv4 = link.IPv4[0].String()
+				}
+				f.table.SetCell(row+0, col, tview.NewTableCell(fmt.Sprintf("%14s",
+					state)).SetTextColor(tcell.ColorLightCyan))
+				f.table.SetCell(row+1, col, tview.NewTableCell(fmt.Sprintf("%14s",
+					link.MAC)).SetTextColor(tcell.ColorLightCyan))
+				f.table.SetCell(row+2, col, tview.NewTableCell(fmt.Sprintf("%14s",
+					ipv4)).SetTextColor(tcell.ColorLightCyan))
+			}
+		}
+
+		f.table.SetCell(row+4, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.InPacketRate)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+1, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+5, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.InPackets)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+2, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+6, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.InBytes/(1024*1024))).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+3, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+7, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.InErrors)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+4, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+8, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.InMissed)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+5, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+9, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.RxInvalid)).SetTextColor(tcell.ColorLightCyan))
 
-		f.table.SetCell(row+7, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+11, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.OutPacketRate)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+8, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+12, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.OutPackets)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+9, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+13, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.OutBytes/(1024*1024))).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+10, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+14, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.OutErrors)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+11, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+15, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.OutDropped)).SetTextColor(tcell.ColorLightCyan))
-		f.table.SetCell(row+12, col, tview.NewTableCell(prt.Sprintf("%14v",
+		f.table.SetCell(row+16, col, tview.NewTableCell(prt.Sprintf("%14v",
 			s.TxInvalid)).SetTextColor(tcell.ColorLightCyan))
 
-		f.table.SetCell(row+14, col, tview.NewTableCell(prt.Sprintf("%14v",
-			len(f.samplingCtx))).SetTextColor(tcell.ColorLightCyan))
+		f.table.SetCell(row+18, col, tview.NewTableCell(prt.Sprintf("%14v",
+			f.samplingCtx.Len())).SetTextColor(tcell.ColorLightCyan))
+
+		if f.exporter != nil {
+			activeFlows, exported, expired, dropped := f.exporter.Stats()
+			f.table.SetCell(row+19, col, tview.NewTableCell(prt.Sprintf("%14v",
+				activeFlows)).SetTextColor(tcell.ColorLightCyan))
+			f.table.SetCell(row+20, col, tview.NewTableCell(prt.Sprintf("%14v",
+				exported)).SetTextColor(tcell.ColorLightCyan))
+			f.table.SetCell(row+21, col, tview.NewTableCell(prt.Sprintf("%14v",
+				expired)).SetTextColor(tcell.ColorLightCyan))
+			f.table.SetCell(row+22, col, tview.NewTableCell(prt.Sprintf("%14v",
+				dropped)).SetTextColor(tcell.ColorLightCyan))
+		}
+
+		if TestFlag == "respond" {
+			rc := f.responderCounters()
+			f.table.SetCell(row+23, col, tview.NewTableCell(prt.Sprintf("%14v",
+				rc.ARPReplies)).SetTextColor(tcell.ColorLightCyan))
+			f.table.SetCell(row+24, col, tview.NewTableCell(prt.Sprintf("%14v",
+				rc.ICMPEchoes)).SetTextColor(tcell.ColorLightCyan))
+			f.table.SetCell(row+25, col, tview.NewTableCell(prt.Sprintf("%14v",
+				rc.ICMPv6Echoes)).SetTextColor(tcell.ColorLightCyan))
+			f.table.SetCell(row+26, col, tview.NewTableCell(prt.Sprintf("%14v",
+				rc.NeighborAdverts)).SetTextColor(tcell.ColorLightCyan))
+		}
 	}
 
 }
@@ -232,11 +385,12 @@ func (f *SamplingInfo) transmitPackets(thdName string, lportNames []string) {
 	// Pkt Type:VLAN ID    :      IPv4 / UDP:0001
 	// IP  Destination     :           198.18.1.1
 	// 	   Source          :        198.18.0.1/24
-	// MAC Destination     :    3c:fd:fe:e4:34:c0
+	// MAC Destination     :    resolved live from the neighbor cache
 	// 	   Source          :    3c:fd:fe:e4:38:44
-	// Make sure the destination MAC address does not match
-	// the port the packet is being sent as the NIC will
-	// drop the packet.
+	// The destination MAC below is only a placeholder: it is overwritten
+	// every iteration from f.linkWatch's neighbor cache so the packet
+	// never goes out addressed back to the sending port, which the NIC
+	// would otherwise drop.
 	//
 	// 0000   3cfd fee4 34c0 3cfd fee4 3844 0800 4500
 	// 0010   002e 60ac 0000 4011 8cec c612 0001 c612
@@ -252,6 +406,16 @@ func (f *SamplingInfo) transmitPackets(thdName string, lportNames []string) {
 		return
 	}
 
+	var srcID l2l3resp.Identity
+	copy(srcID.MAC[:], data[6:12])
+	copy(srcID.IPv4[:], data[26:30])
+	var dstIPv4 [4]byte
+	copy(dstIPv4[:], data[30:34])
+	dstIP := net.IP(dstIPv4[:])
+
+	probePackets := make([]*cne.Packet, 1)
+	lastProbe := make(map[int]time.Time)
+
 	var lportIds []int
 	for _, lport := range lports {
 		lportIds = append(lportIds, lport.LPortID())
@@ -263,6 +427,16 @@ func (f *SamplingInfo) transmitPackets(thdName string, lportNames []string) {
 			case <-f.ctx.Done():
 				return
 			default:
+				dstMAC, ok := f.linkWatch.ResolveMAC(dstIP)
+				if !ok {
+					if now := time.Now(); now.Sub(lastProbe[pid]) >= arpProbeInterval {
+						f.probeDestMAC(pid, probePackets, srcID, dstIPv4)
+						lastProbe[pid] = now
+					}
+					continue
+				}
+				copy(data[0:6], dstMAC)
+
 				size := cne.PktBufferAlloc(pid, txPackets)
 
 				if size != len(txPackets) {
@@ -286,6 +460,19 @@ func (f *SamplingInfo) transmitPackets(thdName string, lportNames []string) {
 	}
 }
 
+// probeDestMAC sends a single ARP request for dstIP out pid so the kernel's
+// neighbor table has a chance to populate before transmitPackets' next
+// iteration retries the f.linkWatch lookup.
+func (f *SamplingInfo) probeDestMAC(pid int, probe []*cne.Packet, srcID l2l3resp.Identity, dstIP [4]byte) {
+	if cne.PktBufferAlloc(pid, probe) != len(probe) {
+		return
+	}
+	if err := cne.WritePktDataList(probe, 0, l2l3resp.BuildARPRequest(srcID, dstIP)); err != nil {
+		return
+	}
+	cne.TxBurst(pid, probe, true)
+}
+
 // retransmit the received packet on the same lport after swapping the MAC addresses
 func (f *SamplingInfo) reTransmitPackets(thdName string, lportNames []string) {
 
@@ -319,6 +506,7 @@ func (f *SamplingInfo) reTransmitPackets(thdName string, lportNames []string) {
 					pkts := packets[:size]
 					var i int
 					for ; i<size; i++ {
+						f.observeFlow(pkts[i], pid)
 						action := f.getSamplingAction(pkts[i])
 						if action != "DROP" {
 							fwdPackets = append(fwdPackets, pkts[i])
@@ -365,6 +553,7 @@ func (f *SamplingInfo) verifyIPv4ChecksumPackets(thdName string, lportNames []st
 				size := cne.RxBurst(pid, packets)
 				if size > 0 {
 					for j := 0; j < size; j++ {
+						f.observeFlow(packets[j], pid)
 						ethHdr := cne.GetEtherHdr(packets[j])
 						if ethHdr.EtherType != cne.SwapUint16(cne.EtherTypeIPV4) &&
 							cne.IPv4Checksum(cne.GetIPv4(packets[j])) != 0 {
@@ -378,6 +567,103 @@ func (f *SamplingInfo) verifyIPv4ChecksumPackets(thdName string, lportNames []st
 	}
 }
 
+// respondPackets answers ARP/ICMP/ICMPv6-ND control-plane traffic for each
+// lport attached to this thread and forwards everything else through the
+// same sampling-and-loopback path as reTransmitPackets.
+func (f *SamplingInfo) respondPackets(thdName string, lportNames []string) {
+
+	lports := f.handle.LPortsByName(lportNames)
+	if len(lports) == 0 {
+		return
+	}
+
+	err := f.handle.RegisterThread(thdName)
+	if err != nil {
+		return
+	}
+	defer f.handle.UnregisterThread(thdName)
+
+	packets := make([]*cne.Packet, 256)
+
+	responders := make(map[int]*l2l3resp.Responder, len(lports))
+	var lportIds []int
+	for _, lport := range lports {
+		lportIds = append(lportIds, lport.LPortID())
+		responders[lport.LPortID()] = l2l3resp.New(f.lportIdentities[lport.Name()])
+	}
+	f.addResponders(responders)
+
+	for {
+		for _, pid := range lportIds {
+			select {
+			case <-f.ctx.Done():
+				return
+			default:
+				size := cne.RxBurst(pid, packets)
+				if size == 0 {
+					continue
+				}
+
+				pkts := packets[:size]
+				replies := make([]*cne.Packet, 0, size)
+				fwdPackets := make([]*cne.Packet, 0, size)
+				responder := responders[pid]
+
+				for i := 0; i < size; i++ {
+					frame := cne.ReadPktData(pkts[i])
+					if reply, ok := responder.Respond(frame); ok {
+						cne.WritePktDataList(pkts[i:i+1], 0, reply)
+						replies = append(replies, pkts[i])
+						continue
+					}
+
+					f.observeFlow(pkts[i], pid)
+					if f.getSamplingAction(pkts[i]) != "DROP" {
+						fwdPackets = append(fwdPackets, pkts[i])
+					}
+				}
+
+				if len(replies) > 0 {
+					cne.TxBurst(pid, replies, true)
+				}
+				if len(fwdPackets) > 0 {
+					cne.SwapMacAddrs(fwdPackets)
+					cne.TxBurst(pid, fwdPackets, true)
+				}
+			}
+		}
+	}
+}
+
+// addResponders registers a thread's per-lport Responders so displayStats
+// can sum their reply counters across every respond-mode thread.
+func (f *SamplingInfo) addResponders(byLPort map[int]*l2l3resp.Responder) {
+	f.respMu.Lock()
+	defer f.respMu.Unlock()
+
+	for _, r := range byLPort {
+		f.responders = append(f.responders, r)
+	}
+}
+
+// responderCounters sums the reply counters across every respond-mode
+// Responder for displayStats.
+func (f *SamplingInfo) responderCounters() l2l3resp.Counters {
+	f.respMu.Lock()
+	defer f.respMu.Unlock()
+
+	var total l2l3resp.Counters
+	for _, r := range f.responders {
+		c := r.Counters()
+		total.ARPReplies += c.ARPReplies
+		total.ICMPEchoes += c.ICMPEchoes
+		total.ICMPv6Echoes += c.ICMPv6Echoes
+		total.NeighborAdverts += c.NeighborAdverts
+	}
+
+	return total
+}
+
 // setup the system signals to trap and handle shutdown
 func (f *SamplingInfo) setupSignals(signals ...os.Signal) {
 
@@ -420,7 +706,7 @@ func samplingSetup() *SamplingInfo {
 		SetTitle(fmt.Sprintf(" %s  TestMode: %s ", cz.Cyan("Press Esc or Q/q or Ctrl-C to quit"),
 			cz.Orange(TestFlag)))
 
-	f.flex0.AddItem(f.table, 18, 1, true)
+	f.flex0.AddItem(f.table, 31, 1, true)
 
 	// Shortcuts to stop application
 	f.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
@@ -454,17 +740,107 @@ func samplingSetup() *SamplingInfo {
 
 	f.stats = make([]*cne.LPortStats, len(f.handle.LPortList()))
 
-	f.samplingCtx = make(map[uint32]uint32)
-	
+	format, err := flowexport.ParseFormat(ExportFlag)
+	if err != nil {
+		log.Fatalf("%s\n", err.Error())
+	}
+	if format != flowexport.FormatNone {
+		exporter, err := flowexport.New(flowexport.Config{
+			Collector: CollectorFlag,
+			Format:    format,
+		})
+		if err != nil {
+			log.Fatalf("unable to start flow exporter: %s\n", err.Error())
+		}
+		exporter.Start()
+		f.exporter = exporter
+	}
+
+	switch PolicyFlag {
+	case "reservoir":
+		f.samplingCtx = sampler.NewReservoir(sampler.ReservoirConfig{
+			Size: ReservoirSizeFlag,
+			TTL:  FlowTTLFlag,
+			Sink: reservoirSink{f},
+		})
+	case "tokenbucket":
+		f.samplingCtx = sampler.NewTokenBucket(sampler.TokenBucketConfig{
+			Rate:  TokenRateFlag,
+			Burst: TokenBurstFlag,
+			TTL:   FlowTTLFlag,
+		})
+	default:
+		log.Fatalf("invalid -policy %q, expected tokenbucket|reservoir\n", PolicyFlag)
+	}
+	go f.expireSamplingCtx()
+
+	linkWatch, err := nlwatch.New()
+	if err != nil {
+		log.Fatalf("unable to open netlink watcher: %s\n", err.Error())
+	}
+	linkWatch.Start()
+	f.linkWatch = linkWatch
+	go func() {
+		<-f.ctx.Done()
+		f.linkWatch.Stop()
+	}()
+
+	if TestFlag == "respond" {
+		identities, err := l2l3resp.LoadIdentities(ConfigFlag)
+		if err != nil {
+			log.Fatalf("unable to load lport identities: %s\n", err.Error())
+		}
+		f.lportIdentities = identities
+	}
+
 	return f
 }
 
+// reservoirSink bridges the reservoir sampler's periodic emits into the
+// flow-export pipeline, keyed by the sampled packet's flow hash.
+type reservoirSink struct {
+	f *SamplingInfo
+}
+
+func (s reservoirSink) EmitSample(hash uint32, samples []sampler.PacketDescriptor) {
+	if s.f.exporter == nil {
+		return
+	}
+	for _, d := range samples {
+		s.f.exporter.ObserveHash(hash, d.Length, d.Timestamp)
+	}
+}
+
+// expireSamplingCtx periodically sweeps the sampling policy for flows that
+// have been idle past their TTL, until the application shuts down.
+func (f *SamplingInfo) expireSamplingCtx() {
+	ticker := time.NewTicker(samplingExpireInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case now := <-ticker.C:
+			f.samplingCtx.Expire(now)
+		}
+	}
+}
+
 func main() {
 	flag.Parse()
 
+	if len(CNIConfigDirFlag) > 0 {
+		path, err := cniconfig.MergeDir(CNIConfigDirFlag)
+		if err != nil {
+			log.Fatalf("unable to merge cni config dir %q: %s\n", CNIConfigDirFlag, err.Error())
+		}
+		ConfigFlag = path
+	}
+
 	if len(ConfigFlag) == 0 {
 		flag.PrintDefaults()
-		log.Fatalf("-c option must be present\n")
+		log.Fatalf("-c or -cni-config option must be present\n")
 	}
 
 	if len(TestFlag) == 0 {
@@ -485,6 +861,9 @@ func main() {
 	defer f.handle.Close()
 	defer f.app.Stop()
 	defer f.stop()
+	if f.exporter != nil {
+		defer f.exporter.Stop()
+	}
 
 	// For each JSON configuration thread create a Go thread and pass
 	// the list of LPorts attached to the thread to the test function.
@@ -503,6 +882,8 @@ func main() {
 			go f.reTransmitPackets(thdName, thd.LPorts)
 		case "chksum":
 			go f.verifyIPv4ChecksumPackets(thdName, thd.LPorts)
+		case "respond":
+			go f.respondPackets(thdName, thd.LPorts)
 		default:
 			log.Fatalf("*** invalid test option")
 			os.Exit(1)
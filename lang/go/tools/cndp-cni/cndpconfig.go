@@ -0,0 +1,117 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// cndpSnippet is the subset of the CNDP JSON config schema this plugin
+// populates: one umem backing one lport, and one thread owning it. It is
+// written verbatim to disk so it can be handed to cne.OpenWithFile by
+// whatever workload runs inside the container.
+type cndpSnippet struct {
+	Umems   map[string]umemConfig   `json:"umems"`
+	LPorts  map[string]lportConfig  `json:"lports"`
+	Threads map[string]threadConfig `json:"threads"`
+}
+
+type umemConfig struct {
+	BufCount    int    `json:"bufcnt"`
+	BufSize     int    `json:"bufsz"`
+	MemType     string `json:"mtype"`
+	Description string `json:"description"`
+}
+
+type lportConfig struct {
+	PMD         string `json:"pmd"`
+	Qid         int    `json:"qid"`
+	Umem        string `json:"umem"`
+	XskMapPath  string `json:"xskMapPath"`
+	BusyPoll    bool   `json:"busyPoll,omitempty"`
+	NeedsWakeup bool   `json:"needsWakeup,omitempty"`
+	Description string `json:"description"`
+}
+
+type threadConfig struct {
+	Group       string   `json:"group"`
+	LPorts      []string `json:"lports"`
+	Description string   `json:"description"`
+}
+
+// buildCNDPSnippet turns a validated NetConf into the CNDP JSON config
+// cne.OpenWithFile expects.
+//
+// Every umem/lport/thread name is derived from containerID rather than a
+// fixed name, because cniconfig.MergeDir unions snippets from multiple
+// containers by these same map keys and lets later files win on
+// collisions: a fixed name would make a second container's entry silently
+// overwrite the first's in the merged config.
+func buildCNDPSnippet(conf *NetConf, containerID string) *cndpSnippet {
+	umemName := "umem-" + containerID
+	lportName := "net-" + containerID
+
+	return &cndpSnippet{
+		Umems: map[string]umemConfig{
+			umemName: {
+				BufCount:    16 * 1024,
+				BufSize:     2048,
+				MemType:     "4Kb",
+				Description: fmt.Sprintf("umem for container %s", containerID),
+			},
+		},
+		LPorts: map[string]lportConfig{
+			lportName: {
+				PMD:         fmt.Sprintf("net_af_xdp%d", conf.Queue),
+				Qid:         conf.Queue,
+				Umem:        umemName,
+				XskMapPath:  conf.XskMapPath,
+				BusyPoll:    conf.BusyPoll,
+				NeedsWakeup: conf.NeedsWakeup,
+				Description: fmt.Sprintf("%s queue %d for container %s", conf.Device, conf.Queue, containerID),
+			},
+		},
+		Threads: map[string]threadConfig{
+			"cni-" + containerID: {
+				LPorts:      []string{lportName},
+				Description: fmt.Sprintf("thread group handed to container %s", containerID),
+			},
+		},
+	}
+}
+
+// writeCNDPSnippet writes snippet as the CNDP config for containerID under
+// dir, creating dir if needed, and returns the path it was written to.
+func writeCNDPSnippet(dir, containerID string, snippet *cndpSnippet) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create results dir %q: %w", dir, err)
+	}
+
+	data, err := json.MarshalIndent(snippet, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal cndp snippet: %w", err)
+	}
+
+	path := filepath.Join(dir, containerID+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("write cndp snippet %q: %w", path, err)
+	}
+
+	return path, nil
+}
+
+// removeCNDPSnippet removes the snippet written for containerID, ignoring
+// a missing file since DEL must be idempotent.
+func removeCNDPSnippet(dir, containerID string) error {
+	err := os.Remove(filepath.Join(dir, containerID+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/CloudNativeDataPlane/cndp/lang/go/bindings/cne"
+)
+
+// lportMAC briefly opens the CNDP config at snippetPath to validate it and
+// read back the MAC address cne assigned the lport, then closes the
+// handle again so the lport is free for the container workload to open
+// for real.
+func lportMAC(snippetPath string) (net.HardwareAddr, error) {
+	handle, err := cne.OpenWithFile(snippetPath)
+	if err != nil {
+		return nil, fmt.Errorf("open cndp config %q: %w", snippetPath, err)
+	}
+	defer handle.Close()
+
+	lports := handle.LPortList()
+	if len(lports) == 0 {
+		return nil, fmt.Errorf("no lports created from %q", snippetPath)
+	}
+
+	return lports[0].MacAddr(), nil
+}
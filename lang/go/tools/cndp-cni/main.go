@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+// Command cndp-cni is a CNI (v1.0.0) plugin that programs a CNDP AF_XDP
+// lport into a container network namespace: on ADD it moves the backing
+// netdev queue into the namespace and drops a CNDP JSON snippet the
+// container's workload can open with cne.OpenWithFile; on DEL it tears
+// the lport down and restores the queue to the host.
+package main
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+
+	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
+	current "github.com/containernetworking/cni/pkg/types/100"
+	"github.com/containernetworking/cni/pkg/version"
+	"github.com/containernetworking/plugins/pkg/ipam"
+	"github.com/containernetworking/plugins/pkg/ns"
+)
+
+func cmdAdd(args *skel.CmdArgs) error {
+	conf, err := parseConfig(args.StdinData)
+	if err != nil {
+		return err
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := moveDeviceToNetns(conf.Device, args.Netns); err != nil {
+		return err
+	}
+
+	snippet := buildCNDPSnippet(conf, args.ContainerID)
+	snippetPath, err := writeCNDPSnippet(conf.ResultsDir, args.ContainerID, snippet)
+	if err != nil {
+		return err
+	}
+
+	// conf.Device now lives inside args.Netns, so the lport must be opened
+	// and validated from that namespace rather than the host's.
+	var mac net.HardwareAddr
+	err = ns.WithNetNSPath(args.Netns, func(ns.NetNS) error {
+		var err error
+		mac, err = lportMAC(snippetPath)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("validate lport for container %s: %w", args.ContainerID, err)
+	}
+
+	result := &current.Result{
+		CNIVersion: current.ImplementedSpecVersion,
+		Interfaces: []*current.Interface{
+			{
+				Name:    args.IfName,
+				Mac:     mac.String(),
+				Sandbox: args.Netns,
+			},
+		},
+	}
+
+	if conf.IPAM.Type != "" {
+		ipamResult, err := ipam.ExecAdd(conf.IPAM.Type, args.StdinData)
+		if err != nil {
+			return fmt.Errorf("ipam add: %w", err)
+		}
+
+		ipamCurrent, err := current.NewResultFromResult(ipamResult)
+		if err != nil {
+			return fmt.Errorf("convert ipam result: %w", err)
+		}
+
+		ifaceIdx := 0
+		for _, ipc := range ipamCurrent.IPs {
+			ipc.Interface = &ifaceIdx
+			result.IPs = append(result.IPs, ipc)
+		}
+	}
+
+	return types.PrintResult(result, conf.CNIVersion)
+}
+
+func cmdDel(args *skel.CmdArgs) error {
+	conf, err := parseConfig(args.StdinData)
+	if err != nil {
+		return err
+	}
+
+	if conf.IPAM.Type != "" {
+		if err := ipam.ExecDel(conf.IPAM.Type, args.StdinData); err != nil {
+			return fmt.Errorf("ipam del: %w", err)
+		}
+	}
+
+	if err := removeCNDPSnippet(conf.ResultsDir, args.ContainerID); err != nil {
+		return fmt.Errorf("remove cndp snippet: %w", err)
+	}
+
+	// The netns may already be gone by the time DEL runs (e.g. the pod
+	// sandbox was force-removed); that is not a failure.
+	if args.Netns == "" {
+		return nil
+	}
+
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	return restoreDeviceFromNetns(conf.Device, args.Netns)
+}
+
+func cmdCheck(args *skel.CmdArgs) error {
+	conf, err := parseConfig(args.StdinData)
+	if err != nil {
+		return err
+	}
+
+	if conf.IPAM.Type != "" {
+		if err := ipam.ExecCheck(conf.IPAM.Type, args.StdinData); err != nil {
+			return fmt.Errorf("ipam check: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func main() {
+	skel.PluginMain(cmdAdd, cmdCheck, cmdDel, version.All, "CNDP AF_XDP CNI plugin v1.0.0")
+}
@@ -0,0 +1,66 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+)
+
+// moveDeviceToNetns moves device from the host namespace into netnsPath.
+// AF_XDP binds to a (netdev, queue) pair rather than a namespace, but the
+// queue only becomes usable inside the container once its netdev is
+// visible there, so the whole device follows the lport in.
+func moveDeviceToNetns(device, netnsPath string) error {
+	link, err := netlink.LinkByName(device)
+	if err != nil {
+		return fmt.Errorf("lookup device %q: %w", device, err)
+	}
+
+	targetNS, err := ns.GetNS(netnsPath)
+	if err != nil {
+		return fmt.Errorf("open netns %q: %w", netnsPath, err)
+	}
+	defer targetNS.Close()
+
+	if err := netlink.LinkSetNsFd(link, int(targetNS.Fd())); err != nil {
+		return fmt.Errorf("move device %q into netns %q: %w", device, netnsPath, err)
+	}
+
+	return nil
+}
+
+// restoreDeviceFromNetns moves device back to the host namespace, for DEL.
+// A missing device (e.g. the netns was already torn down by the runtime)
+// is not an error, since DEL must be idempotent.
+func restoreDeviceFromNetns(device, netnsPath string) error {
+	hostNS, err := ns.GetCurrentNS()
+	if err != nil {
+		return fmt.Errorf("get host netns: %w", err)
+	}
+	defer hostNS.Close()
+
+	err = ns.WithNetNSPath(netnsPath, func(ns.NetNS) error {
+		link, err := netlink.LinkByName(device)
+		if err != nil {
+			if _, ok := err.(netlink.LinkNotFoundError); ok {
+				return nil
+			}
+			return fmt.Errorf("lookup device %q: %w", device, err)
+		}
+
+		return netlink.LinkSetNsFd(link, int(hostNS.Fd()))
+	})
+	if _, ok := err.(ns.NSPathNotExistErr); ok {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("restore device %q from netns %q: %w", device, netnsPath, err)
+	}
+
+	return nil
+}
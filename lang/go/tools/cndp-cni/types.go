@@ -0,0 +1,63 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/containernetworking/cni/pkg/types"
+)
+
+// NetConf is the CNI network configuration this plugin understands, on top
+// of the common fields every CNI plugin accepts.
+type NetConf struct {
+	types.NetConf
+
+	// Device is the host netdev backing the AF_XDP lport, e.g. "eth0".
+	Device string `json:"device"`
+
+	// Queue is the netdev RX/TX queue id the lport binds to.
+	Queue int `json:"queue"`
+
+	// XskMapPath is the bpffs path of the AF_XDP socket map the lport's
+	// XDP program redirects into.
+	XskMapPath string `json:"xskMapPath"`
+
+	// BusyPoll and NeedsWakeup mirror the matching AF_XDP socket options;
+	// both default to off.
+	BusyPoll    bool `json:"busyPoll,omitempty"`
+	NeedsWakeup bool `json:"needsWakeup,omitempty"`
+
+	// ResultsDir is where the plugin drops the CNDP JSON snippet it
+	// generated for this lport, so a long-running CNDP application (such
+	// as the sampling app's -cni-config mode) can discover it without a
+	// static config file. Defaults to DefaultResultsDir.
+	ResultsDir string `json:"resultsDir,omitempty"`
+}
+
+// DefaultResultsDir is used when a NetConf does not set resultsDir.
+const DefaultResultsDir = "/var/run/cndp/results"
+
+// parseConfig unmarshals the CNI network configuration passed to the
+// plugin on stdin.
+func parseConfig(stdin []byte) (*NetConf, error) {
+	conf := &NetConf{}
+	if err := json.Unmarshal(stdin, conf); err != nil {
+		return nil, fmt.Errorf("failed to parse network configuration: %w", err)
+	}
+
+	if conf.Device == "" {
+		return nil, fmt.Errorf("\"device\" field is required")
+	}
+	if conf.XskMapPath == "" {
+		return nil, fmt.Errorf("\"xskMapPath\" field is required")
+	}
+	if conf.ResultsDir == "" {
+		conf.ResultsDir = DefaultResultsDir
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,84 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+// Package cniconfig merges the per-container CNDP JSON snippets dropped by
+// the cndp-cni plugin into a single configuration document that
+// cne.OpenWithFile can consume, so a sampling app running on the host can
+// pick up lports as containers come and go instead of requiring a static
+// config file.
+package cniconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Snippet is the subset of the CNDP JSON config schema cndp-cni writes for
+// each container: one umem and one lport per container, grouped under a
+// thread. The field shapes here must stay in sync with cndp-cni's
+// cndpSnippet.
+type Snippet struct {
+	Umems   map[string]json.RawMessage `json:"umems"`
+	LPorts  map[string]json.RawMessage `json:"lports"`
+	Threads map[string]json.RawMessage `json:"threads"`
+}
+
+// MergeDir reads every *.json file dropped under dir by cndp-cni, unions
+// their umems/lports/threads into a single Snippet and writes it to a new
+// temporary file, returning its path. Files are merged in lexical order of
+// their names and later files win on key collisions; how old a file is
+// plays no part in which one wins.
+func MergeDir(dir string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return "", fmt.Errorf("glob cni config dir %q: %w", dir, err)
+	}
+
+	merged := &Snippet{
+		Umems:   map[string]json.RawMessage{},
+		LPorts:  map[string]json.RawMessage{},
+		Threads: map[string]json.RawMessage{},
+	}
+
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read cni config %q: %w", path, err)
+		}
+
+		var snippet Snippet
+		if err := json.Unmarshal(data, &snippet); err != nil {
+			return "", fmt.Errorf("parse cni config %q: %w", path, err)
+		}
+
+		for name, umem := range snippet.Umems {
+			merged.Umems[name] = umem
+		}
+		for name, lport := range snippet.LPorts {
+			merged.LPorts[name] = lport
+		}
+		for name, thd := range snippet.Threads {
+			merged.Threads[name] = thd
+		}
+	}
+
+	out, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal merged cni config: %w", err)
+	}
+
+	f, err := os.CreateTemp("", "cndp-cni-config-*.json")
+	if err != nil {
+		return "", fmt.Errorf("create merged cni config: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(out); err != nil {
+		return "", fmt.Errorf("write merged cni config %q: %w", f.Name(), err)
+	}
+
+	return f.Name(), nil
+}
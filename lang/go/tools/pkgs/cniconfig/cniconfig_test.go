@@ -0,0 +1,113 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package cniconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeSnippet(t *testing.T, dir, name string, s Snippet) {
+	t.Helper()
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal snippet %q: %v", name, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), data, 0o644); err != nil {
+		t.Fatalf("write snippet %q: %v", name, err)
+	}
+}
+
+// TestMergeDirAdditive verifies that two containers' snippets, keyed by
+// distinct names, both survive the merge rather than one overwriting the
+// other.
+func TestMergeDirAdditive(t *testing.T) {
+	dir := t.TempDir()
+
+	writeSnippet(t, dir, "container-a", Snippet{
+		Umems:   map[string]json.RawMessage{"umem-a": json.RawMessage(`{"bufcnt":1}`)},
+		LPorts:  map[string]json.RawMessage{"net-a": json.RawMessage(`{"qid":0}`)},
+		Threads: map[string]json.RawMessage{"cni-a": json.RawMessage(`{"lports":["net-a"]}`)},
+	})
+	writeSnippet(t, dir, "container-b", Snippet{
+		Umems:   map[string]json.RawMessage{"umem-b": json.RawMessage(`{"bufcnt":1}`)},
+		LPorts:  map[string]json.RawMessage{"net-b": json.RawMessage(`{"qid":0}`)},
+		Threads: map[string]json.RawMessage{"cni-b": json.RawMessage(`{"lports":["net-b"]}`)},
+	})
+
+	path, err := MergeDir(dir)
+	if err != nil {
+		t.Fatalf("MergeDir: %v", err)
+	}
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read merged config: %v", err)
+	}
+
+	var merged Snippet
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("unmarshal merged config: %v", err)
+	}
+
+	for _, name := range []string{"umem-a", "umem-b"} {
+		if _, ok := merged.Umems[name]; !ok {
+			t.Errorf("merged config missing umem %q, containers overwrote each other", name)
+		}
+	}
+	for _, name := range []string{"net-a", "net-b"} {
+		if _, ok := merged.LPorts[name]; !ok {
+			t.Errorf("merged config missing lport %q, containers overwrote each other", name)
+		}
+	}
+	for _, name := range []string{"cni-a", "cni-b"} {
+		if _, ok := merged.Threads[name]; !ok {
+			t.Errorf("merged config missing thread %q, containers overwrote each other", name)
+		}
+	}
+}
+
+// TestMergeDirCollisionLastWins verifies that two snippets sharing a key
+// resolve to the file whose name sorts later, matching the documented
+// last-file-wins behavior.
+func TestMergeDirCollisionLastWins(t *testing.T) {
+	dir := t.TempDir()
+
+	writeSnippet(t, dir, "a-stale", Snippet{
+		Umems: map[string]json.RawMessage{"umem0": json.RawMessage(`{"bufcnt":1}`)},
+	})
+	writeSnippet(t, dir, "b-fresh", Snippet{
+		Umems: map[string]json.RawMessage{"umem0": json.RawMessage(`{"bufcnt":2}`)},
+	})
+
+	path, err := MergeDir(dir)
+	if err != nil {
+		t.Fatalf("MergeDir: %v", err)
+	}
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read merged config: %v", err)
+	}
+
+	var merged Snippet
+	if err := json.Unmarshal(data, &merged); err != nil {
+		t.Fatalf("unmarshal merged config: %v", err)
+	}
+
+	var umem map[string]int
+	if err := json.Unmarshal(merged.Umems["umem0"], &umem); err != nil {
+		t.Fatalf("unmarshal merged umem0: %v", err)
+	}
+	if umem["bufcnt"] != 2 {
+		t.Errorf("umem0 = %v, want the later file (bufcnt 2) to win", umem)
+	}
+}
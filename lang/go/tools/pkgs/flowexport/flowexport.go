@@ -0,0 +1,297 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+// Package flowexport aggregates per-packet observations into 5-tuple keyed
+// flow records and periodically ships them to a collector as IPFIX or sFlow
+// v5 encoded UDP datagrams. Records are flushed either after an active
+// timeout or once an inactive flow has been idle longer than the inactive
+// timeout. When the bounded record cache is full, the least recently
+// touched record is evicted to make room and counted as dropped; it is not
+// exported.
+package flowexport
+
+import (
+	"container/list"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Default timeouts and cache bound used when a Config leaves them at zero.
+const (
+	DefaultActiveTimeout   = 60 * time.Second
+	DefaultInactiveTimeout = 15 * time.Second
+	DefaultMaxFlows        = 1 << 16
+	DefaultFlushInterval   = time.Second
+)
+
+// Format selects the wire encoding used when flow records are exported.
+type Format int
+
+const (
+	FormatNone Format = iota
+	FormatIPFIX
+	FormatSFlow
+)
+
+// ParseFormat converts the -export flag value into a Format.
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "", "none":
+		return FormatNone, nil
+	case "ipfix":
+		return FormatIPFIX, nil
+	case "sflow":
+		return FormatSFlow, nil
+	default:
+		return FormatNone, fmt.Errorf("unknown export format %q, expected ipfix|sflow|none", s)
+	}
+}
+
+// FlowKey is the 5-tuple (plus ingress lport) used to aggregate packets into
+// a single flow record.
+type FlowKey struct {
+	SrcIP       [16]byte
+	DstIP       [16]byte
+	SrcPort     uint16
+	DstPort     uint16
+	Proto       uint8
+	IngressPort uint16
+}
+
+// record is the mutable aggregate kept per FlowKey. It is only ever touched
+// while Exporter.mu is held.
+type record struct {
+	key       FlowKey
+	packets   uint64
+	bytes     uint64
+	firstSeen time.Time
+	lastSeen  time.Time
+	elem      *list.Element
+}
+
+// encoder turns a batch of expired records into one or more UDP datagrams.
+type encoder interface {
+	Encode(seq uint32, recs []*record) [][]byte
+}
+
+// Config configures an Exporter.
+type Config struct {
+	Collector       string // host:port of the flow collector
+	Format          Format
+	ActiveTimeout   time.Duration
+	InactiveTimeout time.Duration
+	MaxFlows        int
+}
+
+func (c *Config) setDefaults() {
+	if c.ActiveTimeout <= 0 {
+		c.ActiveTimeout = DefaultActiveTimeout
+	}
+	if c.InactiveTimeout <= 0 {
+		c.InactiveTimeout = DefaultInactiveTimeout
+	}
+	if c.MaxFlows <= 0 {
+		c.MaxFlows = DefaultMaxFlows
+	}
+}
+
+// Exporter aggregates FlowKey observations and flushes them to a collector.
+// The record cache is bounded to MaxFlows entries using LRU eviction so a
+// scan of many short-lived flows cannot grow the cache without bound.
+type Exporter struct {
+	cfg     Config
+	conn    *net.UDPConn
+	encoder encoder
+	seq     uint32
+
+	mu      sync.Mutex
+	records map[FlowKey]*record
+	lru     *list.List // front = most recently touched
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	exported uint64
+	expired  uint64
+	dropped  uint64
+}
+
+// New creates an Exporter. If cfg.Format is FormatNone or cfg.Collector is
+// empty, the returned Exporter still aggregates records (for displayStats)
+// but never dials out or flushes over the network.
+func New(cfg Config) (*Exporter, error) {
+	cfg.setDefaults()
+
+	e := &Exporter{
+		cfg:     cfg,
+		records: make(map[FlowKey]*record),
+		lru:     list.New(),
+		stop:    make(chan struct{}),
+	}
+
+	switch cfg.Format {
+	case FormatIPFIX:
+		e.encoder = newIPFIXEncoder()
+	case FormatSFlow:
+		e.encoder = newSFlowEncoder()
+	case FormatNone:
+		e.encoder = nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %v", cfg.Format)
+	}
+
+	if cfg.Format != FormatNone && cfg.Collector != "" {
+		addr, err := net.ResolveUDPAddr("udp", cfg.Collector)
+		if err != nil {
+			return nil, fmt.Errorf("resolve collector %q: %w", cfg.Collector, err)
+		}
+		conn, err := net.DialUDP("udp", nil, addr)
+		if err != nil {
+			return nil, fmt.Errorf("dial collector %q: %w", cfg.Collector, err)
+		}
+		e.conn = conn
+	}
+
+	return e, nil
+}
+
+// Start launches the background flush loop. Each call launches another
+// loop; repeated calls are not guarded against.
+func (e *Exporter) Start() {
+	e.wg.Add(1)
+	go e.flushLoop()
+}
+
+// Stop halts the flush loop and closes the collector socket.
+func (e *Exporter) Stop() {
+	close(e.stop)
+	e.wg.Wait()
+	if e.conn != nil {
+		e.conn.Close()
+	}
+}
+
+// Observe folds a single packet observation of length bytes into the flow
+// identified by key, creating the record if this is the first packet seen
+// for that flow.
+func (e *Exporter) Observe(key FlowKey, length int, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	r, found := e.records[key]
+	if !found {
+		if len(e.records) >= e.cfg.MaxFlows {
+			e.evictOldestLocked()
+		}
+		r = &record{key: key, firstSeen: now}
+		r.elem = e.lru.PushFront(r)
+		e.records[key] = r
+	} else {
+		e.lru.MoveToFront(r.elem)
+	}
+
+	r.packets++
+	r.bytes += uint64(length)
+	r.lastSeen = now
+}
+
+// ObserveHash folds a packet observation into a flow identified only by its
+// hash, for callers such as a reservoir sampler that have not kept the full
+// 5-tuple. The hash is packed into the key's source address so samples for
+// the same hash still aggregate into one record.
+func (e *Exporter) ObserveHash(hash uint32, length int, now time.Time) {
+	var key FlowKey
+	binary.BigEndian.PutUint32(key.SrcIP[:4], hash)
+	e.Observe(key, length, now)
+}
+
+// evictOldestLocked drops the least-recently-touched record to keep the
+// cache bounded. Callers must hold e.mu.
+func (e *Exporter) evictOldestLocked() {
+	back := e.lru.Back()
+	if back == nil {
+		return
+	}
+	r := back.Value.(*record)
+	e.lru.Remove(back)
+	delete(e.records, r.key)
+	e.dropped++
+}
+
+// flushLoop periodically scans for flows past their active or inactive
+// timeout and ships them to the collector.
+func (e *Exporter) flushLoop() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(DefaultFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.stop:
+			e.flush(time.Now(), true)
+			return
+		case now := <-ticker.C:
+			e.flush(now, false)
+		}
+	}
+}
+
+// flush removes expired records (or, if all is true, every record) and
+// exports them as a batch.
+func (e *Exporter) flush(now time.Time, all bool) {
+	e.mu.Lock()
+	var expired []*record
+	for _, r := range e.records {
+		if all || now.Sub(r.firstSeen) >= e.cfg.ActiveTimeout || now.Sub(r.lastSeen) >= e.cfg.InactiveTimeout {
+			expired = append(expired, r)
+			e.lru.Remove(r.elem)
+			delete(e.records, r.key)
+		}
+	}
+	e.expired += uint64(len(expired))
+	e.mu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	e.export(expired)
+}
+
+// export encodes and sends a batch of expired records to the collector.
+func (e *Exporter) export(recs []*record) {
+	if e.encoder == nil || e.conn == nil {
+		return
+	}
+
+	e.mu.Lock()
+	e.seq++
+	seq := e.seq
+	e.mu.Unlock()
+
+	var sent uint64
+	for _, pkt := range e.encoder.Encode(seq, recs) {
+		if _, err := e.conn.Write(pkt); err != nil {
+			break
+		}
+		sent++
+	}
+
+	e.mu.Lock()
+	e.exported += sent
+	e.mu.Unlock()
+}
+
+// Stats reports the counters displayStats renders next to each lport column.
+// exported/expired/dropped are kept under e.mu because they are mutated by
+// the flush-loop goroutine and read here from the UI redraw goroutine.
+func (e *Exporter) Stats() (activeFlows int, exported, expired, dropped uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return len(e.records), e.exported, e.expired, e.dropped
+}
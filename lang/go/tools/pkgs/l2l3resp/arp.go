@@ -0,0 +1,100 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package l2l3resp
+
+import "encoding/binary"
+
+// ARP header layout (RFC 826), starting right after the Ethernet header.
+// Only the IPv4-over-Ethernet shape is handled, which is all the hardware
+// and protocol type fields below allow for.
+const (
+	arpHeaderLen = 28
+
+	arpHwTypeEthernet   = 1
+	arpProtoTypeIPv4    = 0x0800
+	arpHwAddrLenEth     = 6
+	arpProtoAddrLenIPv4 = 4
+
+	arpOpRequest = 1
+	arpOpReply   = 2
+
+	arpOff = ethHeaderLen
+
+	arpOpOff  = arpOff + 6
+	arpSHAOff = arpOff + 8
+	arpSPAOff = arpOff + 14
+	arpTHAOff = arpOff + 18
+	arpTPAOff = arpOff + 24
+)
+
+// BuildARPRequest builds a broadcast ARP request frame asking who has
+// targetIP, sourced from id, for a caller that needs to resolve a
+// destination MAC before it can transmit to targetIP.
+func BuildARPRequest(id Identity, targetIP [4]byte) []byte {
+	frame := make([]byte, ethHeaderLen+arpHeaderLen)
+
+	for i := 0; i < 6; i++ {
+		frame[i] = 0xff // broadcast destination
+	}
+	copy(frame[6:12], id.MAC[:])
+	binary.BigEndian.PutUint16(frame[ethTypeOff:], ethTypeARP)
+
+	binary.BigEndian.PutUint16(frame[arpOff:], arpHwTypeEthernet)
+	binary.BigEndian.PutUint16(frame[arpOff+2:], arpProtoTypeIPv4)
+	frame[arpOff+4] = arpHwAddrLenEth
+	frame[arpOff+5] = arpProtoAddrLenIPv4
+	binary.BigEndian.PutUint16(frame[arpOpOff:], arpOpRequest)
+
+	copy(frame[arpSHAOff:arpSHAOff+6], id.MAC[:])
+	copy(frame[arpSPAOff:arpSPAOff+4], id.IPv4[:])
+	// Target hardware address is left zeroed, as is conventional for requests.
+	copy(frame[arpTPAOff:arpTPAOff+4], targetIP[:])
+
+	return frame
+}
+
+// respondARP answers an ARP request for r.id.IPv4 by turning frame into the
+// matching ARP reply in place.
+func (r *Responder) respondARP(frame []byte) ([]byte, bool) {
+	if len(frame) < ethHeaderLen+arpHeaderLen {
+		return frame, false
+	}
+
+	hwType := binary.BigEndian.Uint16(frame[arpOff:])
+	protoType := binary.BigEndian.Uint16(frame[arpOff+2:])
+	hwLen := frame[arpOff+4]
+	protoLen := frame[arpOff+5]
+	op := binary.BigEndian.Uint16(frame[arpOpOff:])
+
+	if hwType != arpHwTypeEthernet || protoType != arpProtoTypeIPv4 ||
+		hwLen != arpHwAddrLenEth || protoLen != arpProtoAddrLenIPv4 || op != arpOpRequest {
+		return frame, false
+	}
+
+	var tpa [4]byte
+	copy(tpa[:], frame[arpTPAOff:arpTPAOff+4])
+	if tpa != r.id.IPv4 {
+		return frame, false
+	}
+
+	var senderMAC [6]byte
+	copy(senderMAC[:], frame[arpSHAOff:arpSHAOff+6])
+	var senderIP [4]byte
+	copy(senderIP[:], frame[arpSPAOff:arpSPAOff+4])
+
+	binary.BigEndian.PutUint16(frame[arpOpOff:], arpOpReply)
+	copy(frame[arpTHAOff:arpTHAOff+6], senderMAC[:])
+	copy(frame[arpTPAOff:arpTPAOff+4], senderIP[:])
+	copy(frame[arpSHAOff:arpSHAOff+6], r.id.MAC[:])
+	copy(frame[arpSPAOff:arpSPAOff+4], r.id.IPv4[:])
+
+	r.swapEthAddrs(frame)
+
+	r.countersMu.Lock()
+	r.counters.ARPReplies++
+	r.countersMu.Unlock()
+
+	return frame, true
+}
@@ -0,0 +1,25 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package l2l3resp
+
+// internetChecksum computes the RFC 1071 ones-complement checksum over buf,
+// as used by ICMP and ICMPv6 (the latter over a pseudo-header-prefixed
+// buffer built by the caller).
+func internetChecksum(buf []byte) uint16 {
+	var sum uint32
+
+	for i := 0; i+1 < len(buf); i += 2 {
+		sum += uint32(buf[i])<<8 | uint32(buf[i+1])
+	}
+	if len(buf)%2 == 1 {
+		sum += uint32(buf[len(buf)-1]) << 8
+	}
+
+	for sum>>16 != 0 {
+		sum = (sum & 0xffff) + (sum >> 16)
+	}
+
+	return ^uint16(sum)
+}
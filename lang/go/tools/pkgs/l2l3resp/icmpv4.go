@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package l2l3resp
+
+import "encoding/binary"
+
+const (
+	ipv4Off        = ethHeaderLen
+	ipv4ProtoOff   = ipv4Off + 9
+	ipv4DstAddrOff = ipv4Off + 16
+	ipv4ProtoICMP  = 1
+
+	icmpTypeEchoRequest = 8
+	icmpTypeEchoReply   = 0
+)
+
+// respondICMPv4 answers an ICMPv4 echo request addressed to r.id.IPv4,
+// turning frame into the matching echo reply in place. The identifier,
+// sequence number, and payload are preserved as required by RFC 792; only
+// the type and checksum fields change.
+func (r *Responder) respondICMPv4(frame []byte) ([]byte, bool) {
+	if len(frame) < ipv4Off+20 {
+		return frame, false
+	}
+
+	ihl := int(frame[ipv4Off]&0x0f) * 4
+	icmpOff := ipv4Off + ihl
+	if frame[ipv4ProtoOff] != ipv4ProtoICMP || len(frame) < icmpOff+8 {
+		return frame, false
+	}
+
+	var dst, src [4]byte
+	copy(dst[:], frame[ipv4DstAddrOff:ipv4DstAddrOff+4])
+	copy(src[:], frame[ipv4Off+12:ipv4Off+16])
+	if dst != r.id.IPv4 || frame[icmpOff] != icmpTypeEchoRequest {
+		return frame, false
+	}
+
+	// Swapping the two address words leaves the IPv4 header checksum
+	// unchanged, since an Internet checksum is a sum over 16-bit words
+	// independent of their order.
+	copy(frame[ipv4Off+12:ipv4Off+16], dst[:])
+	copy(frame[ipv4DstAddrOff:ipv4DstAddrOff+4], src[:])
+
+	frame[icmpOff] = icmpTypeEchoReply
+	binary.BigEndian.PutUint16(frame[icmpOff+2:], 0)
+	csum := internetChecksum(frame[icmpOff:])
+	binary.BigEndian.PutUint16(frame[icmpOff+2:], csum)
+
+	r.swapEthAddrs(frame)
+
+	r.countersMu.Lock()
+	r.counters.ICMPEchoes++
+	r.countersMu.Unlock()
+
+	return frame, true
+}
@@ -0,0 +1,160 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package l2l3resp
+
+import "encoding/binary"
+
+const (
+	ipv6Off           = ethHeaderLen
+	ipv6HeaderLen     = 40
+	ipv6PayloadLenOff = ipv6Off + 4
+	ipv6NextHdrOff    = ipv6Off + 6
+	ipv6SrcOff        = ipv6Off + 8
+	ipv6DstOff        = ipv6Off + 24
+	ipv6NextHdrICMPv6 = 58
+
+	icmpv6Off = ipv6Off + ipv6HeaderLen
+
+	icmpv6TypeEchoRequest = 128
+	icmpv6TypeEchoReply   = 129
+
+	icmpv6TypeNeighborSolicit = 135
+	icmpv6TypeNeighborAdvert  = 136
+
+	ndOptTargetLinkLayerAddr = 2
+
+	ndFlagSolicited = 1 << 30
+	ndFlagOverride  = 1 << 29
+)
+
+// respondICMPv6 answers an ICMPv6 echo request or neighbor solicitation
+// addressed to r.id.IPv6, returning the reply.
+func (r *Responder) respondICMPv6(frame []byte) ([]byte, bool) {
+	if len(frame) < icmpv6Off+8 || frame[ipv6NextHdrOff] != ipv6NextHdrICMPv6 {
+		return frame, false
+	}
+
+	var dst [16]byte
+	copy(dst[:], frame[ipv6DstOff:ipv6DstOff+16])
+	if dst != r.id.IPv6 {
+		return frame, false
+	}
+
+	switch frame[icmpv6Off] {
+	case icmpv6TypeEchoRequest:
+		return r.respondICMPv6Echo(frame)
+	case icmpv6TypeNeighborSolicit:
+		return r.respondNeighborSolicit(frame)
+	default:
+		return frame, false
+	}
+}
+
+// respondICMPv6Echo turns an ICMPv6 echo request into an echo reply,
+// preserving the identifier, sequence number, and payload.
+func (r *Responder) respondICMPv6Echo(frame []byte) ([]byte, bool) {
+	var src [16]byte
+	copy(src[:], frame[ipv6SrcOff:ipv6SrcOff+16])
+	copy(frame[ipv6SrcOff:ipv6SrcOff+16], r.id.IPv6[:])
+	copy(frame[ipv6DstOff:ipv6DstOff+16], src[:])
+
+	frame[icmpv6Off] = icmpv6TypeEchoReply
+	r.recomputeICMPv6Checksum(frame)
+
+	r.swapEthAddrs(frame)
+
+	r.countersMu.Lock()
+	r.counters.ICMPv6Echoes++
+	r.countersMu.Unlock()
+
+	return frame, true
+}
+
+// respondNeighborSolicit turns a Neighbor Solicitation targeting r.id.IPv6
+// into a Neighbor Advertisement with the Solicited and Override flags set
+// and a Target Link-Layer Address option carrying r.id.MAC.
+//
+// A minimal incoming Neighbor Solicitation (e.g. one sent for duplicate
+// address detection) carries no options and so can be shorter than the
+// Advertisement this responder always sends back, which adds a Target
+// Link-Layer Address option. When frame has no room for that option, the
+// reply is built in a freshly allocated buffer instead of appended past
+// the end of frame's backing array.
+func (r *Responder) respondNeighborSolicit(frame []byte) ([]byte, bool) {
+	const (
+		nsTargetOff = icmpv6Off + 8
+		nsMinLen    = nsTargetOff + 16 // 8B ICMPv6 header + 16B target address
+		naFlagsOff  = icmpv6Off + 4
+		naTargetOff = icmpv6Off + 8
+		naOptOff    = icmpv6Off + 24 // 8B ICMPv6 header + 16B target address
+		naLen       = naOptOff + 8   // + 8B Target Link-Layer Address option
+	)
+
+	if len(frame) < nsMinLen {
+		return frame, false
+	}
+
+	var target [16]byte
+	copy(target[:], frame[nsTargetOff:nsTargetOff+16])
+	if target != r.id.IPv6 {
+		return frame, false
+	}
+
+	reply := frame
+	if len(reply) < naLen {
+		reply = make([]byte, naLen)
+		copy(reply, frame[:nsMinLen])
+	}
+
+	var src [16]byte
+	copy(src[:], reply[ipv6SrcOff:ipv6SrcOff+16])
+	copy(reply[ipv6SrcOff:ipv6SrcOff+16], r.id.IPv6[:])
+	copy(reply[ipv6DstOff:ipv6DstOff+16], src[:])
+
+	reply[icmpv6Off] = icmpv6TypeNeighborAdvert
+	binary.BigEndian.PutUint32(reply[naFlagsOff:], ndFlagSolicited|ndFlagOverride)
+	copy(reply[naTargetOff:naTargetOff+16], r.id.IPv6[:])
+
+	reply[naOptOff] = ndOptTargetLinkLayerAddr
+	reply[naOptOff+1] = 1 // option length in units of 8 octets
+	copy(reply[naOptOff+2:naOptOff+8], r.id.MAC[:])
+
+	binary.BigEndian.PutUint16(reply[ipv6PayloadLenOff:], uint16(naLen-icmpv6Off))
+
+	r.recomputeICMPv6Checksum(reply)
+
+	r.swapEthAddrs(reply)
+
+	r.countersMu.Lock()
+	r.counters.NeighborAdverts++
+	r.countersMu.Unlock()
+
+	return reply, true
+}
+
+// recomputeICMPv6Checksum recomputes the ICMPv6 checksum over the IPv6
+// pseudo-header (RFC 8200 §8.1) followed by the ICMPv6 message, whose
+// length is taken from the IPv6 Payload Length field so that any slack
+// past the message in frame is left out.
+func (r *Responder) recomputeICMPv6Checksum(frame []byte) {
+	icmpv6Len := int(binary.BigEndian.Uint16(frame[ipv6PayloadLenOff:]))
+	if icmpv6Off+icmpv6Len > len(frame) {
+		icmpv6Len = len(frame) - icmpv6Off
+	}
+
+	var pseudo []byte
+	pseudo = append(pseudo, frame[ipv6SrcOff:ipv6SrcOff+16]...)
+	pseudo = append(pseudo, frame[ipv6DstOff:ipv6DstOff+16]...)
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(icmpv6Len))
+	pseudo = append(pseudo, lenBuf[:]...)
+	pseudo = append(pseudo, 0, 0, 0, ipv6NextHdrICMPv6)
+
+	binary.BigEndian.PutUint16(frame[icmpv6Off+2:], 0)
+	pseudo = append(pseudo, frame[icmpv6Off:icmpv6Off+icmpv6Len]...)
+
+	csum := internetChecksum(pseudo)
+	binary.BigEndian.PutUint16(frame[icmpv6Off+2:], csum)
+}
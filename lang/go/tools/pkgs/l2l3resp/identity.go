@@ -0,0 +1,71 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package l2l3resp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+)
+
+// lportIdentityConfig mirrors the "lports" section of the CNDP JSON config
+// file, keyed by lport name the same way cne.JsonCfg keys its ThreadInfoMap
+// entries' LPorts by name. Only the identity fields this package cares
+// about are declared; unknown fields in each lport's entry are ignored.
+type lportIdentityConfig struct {
+	LPorts map[string]struct {
+		MAC  string `json:"mac"`
+		IPv4 string `json:"ipv4"`
+		IPv6 string `json:"ipv6"`
+	} `json:"lports"`
+}
+
+// LoadIdentities reads the lport identity fields of the JSON config at path
+// and returns each named lport's Identity. An lport missing from the
+// section, or with a field left blank, gets the corresponding zero address
+// and simply never matches traffic for that protocol.
+func LoadIdentities(path string) (map[string]Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config %q: %w", path, err)
+	}
+
+	var cfg lportIdentityConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse config %q: %w", path, err)
+	}
+
+	identities := make(map[string]Identity, len(cfg.LPorts))
+	for name, lp := range cfg.LPorts {
+		var id Identity
+
+		if lp.MAC != "" {
+			mac, err := net.ParseMAC(lp.MAC)
+			if err != nil {
+				return nil, fmt.Errorf("lport %s: invalid mac %q: %w", name, lp.MAC, err)
+			}
+			copy(id.MAC[:], mac)
+		}
+		if lp.IPv4 != "" {
+			ip := net.ParseIP(lp.IPv4).To4()
+			if ip == nil {
+				return nil, fmt.Errorf("lport %s: invalid ipv4 %q", name, lp.IPv4)
+			}
+			copy(id.IPv4[:], ip)
+		}
+		if lp.IPv6 != "" {
+			ip := net.ParseIP(lp.IPv6).To16()
+			if ip == nil {
+				return nil, fmt.Errorf("lport %s: invalid ipv6 %q", name, lp.IPv6)
+			}
+			copy(id.IPv6[:], ip)
+		}
+
+		identities[name] = id
+	}
+
+	return identities, nil
+}
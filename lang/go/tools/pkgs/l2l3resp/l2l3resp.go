@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+// Package l2l3resp implements a minimal control-plane responder for the
+// sampling app's "respond" test mode: it inspects raw Ethernet frames and,
+// for ARP requests and ICMPv4/ICMPv6 echo requests and neighbor
+// solicitations addressed to the port's own identity, synthesizes the
+// matching reply (usually in place, without involving the user forwarding
+// path). Every other frame is left untouched so the caller can fall
+// through to normal forwarding.
+package l2l3resp
+
+import (
+	"encoding/binary"
+	"sync"
+)
+
+const (
+	ethHeaderLen = 14
+	ethTypeOff   = 12
+
+	ethTypeARP  = 0x0806
+	ethTypeIPv4 = 0x0800
+	ethTypeIPv6 = 0x86DD
+)
+
+// Identity is the L2/L3 address set a Responder answers on behalf of,
+// loaded from the port's lport configuration.
+type Identity struct {
+	MAC  [6]byte
+	IPv4 [4]byte
+	IPv6 [16]byte
+}
+
+// Counters tallies the replies a Responder has synthesized, rendered by
+// displayStats.
+type Counters struct {
+	ARPReplies      uint64
+	ICMPEchoes      uint64
+	ICMPv6Echoes    uint64
+	NeighborAdverts uint64
+}
+
+// Responder answers ARP requests, ICMPv4/ICMPv6 echo requests, and ICMPv6
+// neighbor solicitations targeting id, normally in place on the supplied
+// buffer.
+type Responder struct {
+	id Identity
+
+	countersMu sync.Mutex
+	counters   Counters
+}
+
+// New creates a Responder that answers on behalf of id.
+func New(id Identity) *Responder {
+	return &Responder{id: id}
+}
+
+// Respond inspects frame and, if it is a control-plane packet addressed to
+// the responder's identity, returns the reply and true. The reply is
+// usually frame itself, rewritten in place, but a Neighbor Advertisement
+// carries a Target Link-Layer Address option a minimal incoming Neighbor
+// Solicitation may not have left room for, in which case the reply is a
+// freshly allocated buffer instead. Frames that do not match fall through
+// unmodified and Respond returns frame, false, meaning the caller's own
+// forwarding logic applies.
+func (r *Responder) Respond(frame []byte) ([]byte, bool) {
+	if len(frame) < ethHeaderLen {
+		return frame, false
+	}
+
+	switch binary.BigEndian.Uint16(frame[ethTypeOff:]) {
+	case ethTypeARP:
+		return r.respondARP(frame)
+	case ethTypeIPv4:
+		return r.respondICMPv4(frame)
+	case ethTypeIPv6:
+		return r.respondICMPv6(frame)
+	default:
+		return frame, false
+	}
+}
+
+// Counters returns a snapshot of the reply counters. Safe to call
+// concurrently with Respond, since the counters are also incremented from
+// the worker goroutine that calls Respond while this is read from the UI
+// redraw goroutine.
+func (r *Responder) Counters() Counters {
+	r.countersMu.Lock()
+	defer r.countersMu.Unlock()
+
+	return r.counters
+}
+
+// swapEthAddrs moves the sender's MAC into the destination field and sets
+// the source MAC to the responder's own identity, as every reply here is
+// sent back out the port it arrived on.
+func (r *Responder) swapEthAddrs(frame []byte) {
+	copy(frame[0:6], frame[6:12])
+	copy(frame[6:12], r.id.MAC[:])
+}
@@ -0,0 +1,182 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package l2l3resp
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func testIdentity() Identity {
+	var id Identity
+	copy(id.MAC[:], net.HardwareAddr{0x02, 0x00, 0x00, 0x00, 0x00, 0x01})
+	copy(id.IPv4[:], net.ParseIP("10.0.0.1").To4())
+	copy(id.IPv6[:], net.ParseIP("fd00::1").To16())
+
+	return id
+}
+
+func TestInternetChecksum(t *testing.T) {
+	// RFC 1071 worked example.
+	buf := []byte{0x00, 0x01, 0xf2, 0x03, 0xf4, 0xf5, 0xf6, 0xf7}
+	if got := internetChecksum(buf); got != 0x220d {
+		t.Errorf("internetChecksum(%x) = %#04x, want 0x220d", buf, got)
+	}
+
+	// A buffer that already carries its own correct checksum, verified by
+	// zeroing the checksum field and recomputing, must round-trip to zero
+	// when re-summed with the checksum back in place.
+	withCsum := append([]byte(nil), buf...)
+	csum := internetChecksum(buf)
+	withCsum = append(withCsum, byte(csum>>8), byte(csum))
+	if got := internetChecksum(withCsum); got != 0 {
+		t.Errorf("internetChecksum of buffer+its own checksum = %#04x, want 0", got)
+	}
+}
+
+func buildARPRequestFrame(id Identity, senderIP [4]byte, targetIP [4]byte) []byte {
+	frame := make([]byte, ethHeaderLen+arpHeaderLen)
+
+	copy(frame[0:6], id.MAC[:]) // destination, irrelevant to respondARP
+	copy(frame[6:12], []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x02})
+	binary.BigEndian.PutUint16(frame[ethTypeOff:], ethTypeARP)
+
+	binary.BigEndian.PutUint16(frame[arpOff:], arpHwTypeEthernet)
+	binary.BigEndian.PutUint16(frame[arpOff+2:], arpProtoTypeIPv4)
+	frame[arpOff+4] = arpHwAddrLenEth
+	frame[arpOff+5] = arpProtoAddrLenIPv4
+	binary.BigEndian.PutUint16(frame[arpOpOff:], arpOpRequest)
+
+	copy(frame[arpSHAOff:arpSHAOff+6], frame[6:12])
+	copy(frame[arpSPAOff:arpSPAOff+4], senderIP[:])
+	copy(frame[arpTPAOff:arpTPAOff+4], targetIP[:])
+
+	return frame
+}
+
+func TestRespondARP(t *testing.T) {
+	id := testIdentity()
+	r := New(id)
+
+	senderMAC := [6]byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x02}
+	senderIP := [4]byte{10, 0, 0, 2}
+	frame := buildARPRequestFrame(id, senderIP, id.IPv4)
+
+	reply, ok := r.Respond(frame)
+	if !ok {
+		t.Fatal("Respond returned false for a matching ARP request")
+	}
+	frame = reply
+
+	if op := binary.BigEndian.Uint16(frame[arpOpOff:]); op != arpOpReply {
+		t.Errorf("ARP op = %d, want arpOpReply", op)
+	}
+	var tha [6]byte
+	copy(tha[:], frame[arpTHAOff:arpTHAOff+6])
+	if tha != senderMAC {
+		t.Errorf("THA = %x, want original sender MAC %x", tha, senderMAC)
+	}
+	var sha [6]byte
+	copy(sha[:], frame[arpSHAOff:arpSHAOff+6])
+	if sha != id.MAC {
+		t.Errorf("SHA = %x, want responder MAC %x", sha, id.MAC)
+	}
+	if r.Counters().ARPReplies != 1 {
+		t.Errorf("ARPReplies = %d, want 1", r.Counters().ARPReplies)
+	}
+
+	// A request for a different target IP must fall through untouched.
+	other := buildARPRequestFrame(id, senderIP, [4]byte{10, 0, 0, 99})
+	if _, ok := r.Respond(other); ok {
+		t.Error("Respond returned true for an ARP request targeting a different IP")
+	}
+}
+
+func buildNeighborSolicit(id Identity, srcIP, targetIP [16]byte) []byte {
+	frame := make([]byte, icmpv6Off+8+16)
+
+	binary.BigEndian.PutUint16(frame[ethTypeOff:], ethTypeIPv6)
+	frame[ipv6NextHdrOff] = ipv6NextHdrICMPv6
+	copy(frame[ipv6SrcOff:ipv6SrcOff+16], srcIP[:])
+	copy(frame[ipv6DstOff:ipv6DstOff+16], id.IPv6[:])
+
+	frame[icmpv6Off] = icmpv6TypeNeighborSolicit
+	copy(frame[icmpv6Off+8:icmpv6Off+8+16], targetIP[:])
+
+	return frame
+}
+
+// TestRespondNeighborSolicit exercises a minimal, fully valid incoming
+// Neighbor Solicitation (e.g. as sent for duplicate address detection):
+// Ethernet + IPv6 + an 8-byte ICMPv6 header + a 16-byte target address,
+// with no options. That frame has no room for the 8-byte Target
+// Link-Layer Address option this responder always appends to its
+// Advertisement, so the reply must come back in a grown buffer rather
+// than being silently dropped.
+func TestRespondNeighborSolicit(t *testing.T) {
+	id := testIdentity()
+	r := New(id)
+
+	var srcIP [16]byte
+	copy(srcIP[:], net.ParseIP("fd00::2").To16())
+
+	frame := buildNeighborSolicit(id, srcIP, id.IPv6)
+
+	reply, ok := r.Respond(frame)
+	if !ok {
+		t.Fatal("Respond returned false for a matching Neighbor Solicitation")
+	}
+
+	const naOptOff = icmpv6Off + 24
+	if len(reply) < naOptOff+8 {
+		t.Fatalf("reply is %d bytes, too short for the Target Link-Layer Address option", len(reply))
+	}
+
+	if got := reply[icmpv6Off]; got != icmpv6TypeNeighborAdvert {
+		t.Errorf("ICMPv6 type = %d, want icmpv6TypeNeighborAdvert", got)
+	}
+
+	var dst [16]byte
+	copy(dst[:], reply[ipv6DstOff:ipv6DstOff+16])
+	if dst != srcIP {
+		t.Errorf("dst IP = %v, want original source %v", dst, srcIP)
+	}
+
+	if reply[naOptOff] != ndOptTargetLinkLayerAddr {
+		t.Errorf("ND option type = %d, want ndOptTargetLinkLayerAddr", reply[naOptOff])
+	}
+	var tlla [6]byte
+	copy(tlla[:], reply[naOptOff+2:naOptOff+8])
+	if tlla != id.MAC {
+		t.Errorf("target link-layer address = %x, want responder MAC %x", tlla, id.MAC)
+	}
+	if r.Counters().NeighborAdverts != 1 {
+		t.Errorf("NeighborAdverts = %d, want 1", r.Counters().NeighborAdverts)
+	}
+}
+
+// TestRespondNeighborSolicitInPlace exercises the common case where the
+// incoming frame already has slack for the Target Link-Layer Address
+// option (e.g. the caller's packet buffer is MTU-sized), in which case the
+// reply is written in place rather than reallocated.
+func TestRespondNeighborSolicitInPlace(t *testing.T) {
+	id := testIdentity()
+	r := New(id)
+
+	var srcIP [16]byte
+	copy(srcIP[:], net.ParseIP("fd00::2").To16())
+
+	frame := buildNeighborSolicit(id, srcIP, id.IPv6)
+	frame = append(frame, make([]byte, 32)...) // MTU-sized slack past the minimal NS
+
+	reply, ok := r.Respond(frame)
+	if !ok {
+		t.Fatal("Respond returned false for a matching Neighbor Solicitation")
+	}
+	if &reply[0] != &frame[0] {
+		t.Error("Respond reallocated the reply even though frame already had room")
+	}
+}
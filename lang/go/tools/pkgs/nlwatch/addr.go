@@ -0,0 +1,60 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package nlwatch
+
+import (
+	"encoding/binary"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyAddr applies an RTM_NEWADDR/RTM_DELADDR message to the owning link's
+// address list.
+func (w *Watcher) applyAddr(data []byte, add bool) {
+	if len(data) < unix.SizeofIfAddrmsg {
+		return
+	}
+
+	family := data[0]
+	index := int(binary.LittleEndian.Uint32(data[4:8]))
+
+	var ip net.IP
+	for _, a := range parseAttrs(data[unix.SizeofIfAddrmsg:]) {
+		if a.Type == unix.IFA_LOCAL || a.Type == unix.IFA_ADDRESS {
+			ip = net.IP(append([]byte(nil), a.Value...))
+		}
+	}
+	if ip == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	link := w.linkFor(index)
+	switch family {
+	case unix.AF_INET:
+		link.IPv4 = updateIPList(link.IPv4, ip, add)
+	case unix.AF_INET6:
+		link.IPv6 = updateIPList(link.IPv6, ip, add)
+	}
+}
+
+// updateIPList adds or removes ip from list, treating list as a set.
+func updateIPList(list []net.IP, ip net.IP, add bool) []net.IP {
+	for i, existing := range list {
+		if existing.Equal(ip) {
+			if add {
+				return list
+			}
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	if add {
+		return append(list, ip)
+	}
+	return list
+}
@@ -0,0 +1,59 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package nlwatch
+
+import (
+	"encoding/binary"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// ifOperUp is IF_OPER_UP from <linux/if.h>, which golang.org/x/sys/unix does
+// not define.
+const ifOperUp = 6
+
+// applyLink applies an RTM_NEWLINK/RTM_DELLINK message to the link cache.
+func (w *Watcher) applyLink(data []byte, up bool) {
+	if len(data) < unix.SizeofIfInfomsg {
+		return
+	}
+
+	index := int(int32(binary.LittleEndian.Uint32(data[4:8])))
+	flags := binary.LittleEndian.Uint32(data[8:12])
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !up {
+		if link := w.links[index]; link != nil {
+			delete(w.names, link.Name)
+		}
+		delete(w.links, index)
+		return
+	}
+
+	link := w.linkFor(index)
+	link.AdminUp = flags&unix.IFF_UP != 0
+	link.OperUp = flags&unix.IFF_RUNNING != 0
+
+	for _, a := range parseAttrs(data[unix.SizeofIfInfomsg:]) {
+		switch a.Type {
+		case unix.IFLA_IFNAME:
+			link.Name = cString(a.Value)
+			w.names[link.Name] = index
+		case unix.IFLA_ADDRESS:
+			link.MAC = net.HardwareAddr(append([]byte(nil), a.Value...))
+		case unix.IFLA_MTU:
+			if len(a.Value) >= 4 {
+				link.MTU = int(binary.LittleEndian.Uint32(a.Value))
+			}
+		case unix.IFLA_OPERSTATE:
+			if len(a.Value) >= 1 {
+				link.OperUp = a.Value[0] == ifOperUp
+			}
+		}
+	}
+}
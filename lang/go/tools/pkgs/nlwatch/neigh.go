@@ -0,0 +1,44 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package nlwatch
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// applyNeigh applies an RTM_NEWNEIGH/RTM_DELNEIGH message to the neighbor
+// cache. A NEWNEIGH with no link-layer address (e.g. an entry that just
+// went stale) is treated the same as a delete, since it is no longer safe
+// to transmit to.
+func (w *Watcher) applyNeigh(data []byte, add bool) {
+	if len(data) < unix.SizeofNdMsg {
+		return
+	}
+
+	var ip net.IP
+	var mac net.HardwareAddr
+	for _, a := range parseAttrs(data[unix.SizeofNdMsg:]) {
+		switch a.Type {
+		case unix.NDA_DST:
+			ip = net.IP(append([]byte(nil), a.Value...))
+		case unix.NDA_LLADDR:
+			mac = net.HardwareAddr(append([]byte(nil), a.Value...))
+		}
+	}
+	if ip == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !add || mac == nil {
+		delete(w.neigh, ip.String())
+		return
+	}
+	w.neigh[ip.String()] = mac
+}
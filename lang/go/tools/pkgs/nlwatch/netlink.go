@@ -0,0 +1,88 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package nlwatch
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"golang.org/x/sys/unix"
+)
+
+// netlinkMessage is a single rtnetlink message split into its header and
+// the payload that follows it.
+type netlinkMessage struct {
+	header unix.NlMsghdr
+	data   []byte
+}
+
+// nlmAlign rounds n up to the 4-byte boundary every netlink message and
+// attribute is padded to.
+func nlmAlign(n int) int {
+	return (n + 3) &^ 3
+}
+
+// parseNetlinkMessages splits a raw recvfrom buffer into its netlink
+// messages. Malformed trailing data is dropped rather than erroring, since
+// a partial read just means the next recvfrom picks up where this one left
+// off.
+func parseNetlinkMessages(b []byte) []netlinkMessage {
+	var msgs []netlinkMessage
+
+	for len(b) >= unix.SizeofNlMsghdr {
+		length := binary.LittleEndian.Uint32(b[0:4])
+		if length < unix.SizeofNlMsghdr || int(length) > len(b) {
+			break
+		}
+
+		msgs = append(msgs, netlinkMessage{
+			header: unix.NlMsghdr{
+				Len:   length,
+				Type:  binary.LittleEndian.Uint16(b[4:6]),
+				Flags: binary.LittleEndian.Uint16(b[6:8]),
+				Seq:   binary.LittleEndian.Uint32(b[8:12]),
+				Pid:   binary.LittleEndian.Uint32(b[12:16]),
+			},
+			data: b[unix.SizeofNlMsghdr:length],
+		})
+
+		b = b[nlmAlign(int(length)):]
+	}
+
+	return msgs
+}
+
+// rtAttr is a single parsed netlink route attribute.
+type rtAttr struct {
+	Type  uint16
+	Value []byte
+}
+
+// parseAttrs parses the TLV attribute list that follows the fixed-size
+// family-specific header of an rtnetlink message.
+func parseAttrs(b []byte) []rtAttr {
+	var attrs []rtAttr
+
+	for len(b) >= unix.SizeofRtAttr {
+		length := binary.LittleEndian.Uint16(b[0:2])
+		typ := binary.LittleEndian.Uint16(b[2:4])
+		if int(length) < unix.SizeofRtAttr || int(length) > len(b) {
+			break
+		}
+
+		attrs = append(attrs, rtAttr{Type: typ, Value: b[unix.SizeofRtAttr:length]})
+		b = b[nlmAlign(int(length)):]
+	}
+
+	return attrs
+}
+
+// cString trims the trailing NUL(s) the kernel pads string attributes with.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
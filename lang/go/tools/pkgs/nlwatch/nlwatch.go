@@ -0,0 +1,155 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+// Package nlwatch watches the kernel's rtnetlink notifications for every
+// netdev in the namespace and keeps a live cache of their addressing and
+// link state, plus a
+// system-wide neighbor (ARP/NDP) table. It lets callers resolve a
+// destination MAC without having to shell out to ip(8) or keep their own
+// static tables in sync with the host.
+package nlwatch
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"golang.org/x/sys/unix"
+)
+
+// LinkState is a netdev's addressing and link state as last reported by the
+// kernel.
+type LinkState struct {
+	Index   int
+	Name    string
+	MAC     net.HardwareAddr
+	MTU     int
+	AdminUp bool
+	OperUp  bool
+	IPv4    []net.IP
+	IPv6    []net.IP
+}
+
+// Watcher keeps LinkState and a neighbor cache up to date by subscribing to
+// rtnetlink link, address and neighbor notifications.
+type Watcher struct {
+	fd int
+
+	mu    sync.RWMutex
+	links map[int]*LinkState
+	names map[string]int
+	neigh map[string]net.HardwareAddr
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New opens an rtnetlink socket subscribed to link, address and neighbor
+// groups. Callers still need to call Start to begin applying notifications.
+func New() (*Watcher, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("open netlink socket: %w", err)
+	}
+
+	groups := uint32(unix.RTMGRP_LINK | unix.RTMGRP_IPV4_IFADDR | unix.RTMGRP_IPV6_IFADDR | unix.RTMGRP_NEIGH)
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: groups}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("bind netlink socket: %w", err)
+	}
+
+	return &Watcher{
+		fd:    fd,
+		links: make(map[int]*LinkState),
+		names: make(map[string]int),
+		neigh: make(map[string]net.HardwareAddr),
+		stop:  make(chan struct{}),
+	}, nil
+}
+
+// Start launches the background read loop that applies incoming
+// notifications to the cache.
+func (w *Watcher) Start() {
+	w.wg.Add(1)
+	go w.readLoop()
+}
+
+// Stop closes the netlink socket, which unblocks the read loop, and waits
+// for it to exit.
+func (w *Watcher) Stop() {
+	close(w.stop)
+	unix.Close(w.fd)
+	w.wg.Wait()
+}
+
+func (w *Watcher) readLoop() {
+	defer w.wg.Done()
+
+	buf := make([]byte, unix.Getpagesize())
+	for {
+		n, _, err := unix.Recvfrom(w.fd, buf, 0)
+		if err != nil {
+			select {
+			case <-w.stop:
+				return
+			default:
+				continue
+			}
+		}
+		w.applyMessages(buf[:n])
+	}
+}
+
+func (w *Watcher) applyMessages(b []byte) {
+	for _, m := range parseNetlinkMessages(b) {
+		switch m.header.Type {
+		case unix.RTM_NEWLINK:
+			w.applyLink(m.data, true)
+		case unix.RTM_DELLINK:
+			w.applyLink(m.data, false)
+		case unix.RTM_NEWADDR:
+			w.applyAddr(m.data, true)
+		case unix.RTM_DELADDR:
+			w.applyAddr(m.data, false)
+		case unix.RTM_NEWNEIGH:
+			w.applyNeigh(m.data, true)
+		case unix.RTM_DELNEIGH:
+			w.applyNeigh(m.data, false)
+		}
+	}
+}
+
+// Link returns a snapshot of the last known state of the netdev named name.
+func (w *Watcher) Link(name string) (LinkState, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	index, ok := w.names[name]
+	if !ok {
+		return LinkState{}, false
+	}
+	link := w.links[index]
+	if link == nil {
+		return LinkState{}, false
+	}
+	return *link, true
+}
+
+// ResolveMAC looks up ip in the neighbor cache.
+func (w *Watcher) ResolveMAC(ip net.IP) (net.HardwareAddr, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	mac, ok := w.neigh[ip.String()]
+	return mac, ok
+}
+
+func (w *Watcher) linkFor(index int) *LinkState {
+	link := w.links[index]
+	if link == nil {
+		link = &LinkState{Index: index}
+		w.links[index] = link
+	}
+	return link
+}
@@ -0,0 +1,183 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package sampler
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// PacketDescriptor is the per-packet summary kept in a flow's reservoir.
+type PacketDescriptor struct {
+	Hash      uint32
+	Length    int
+	Timestamp time.Time
+}
+
+// Sink receives a flow's reservoir contents when it is emitted, e.g. to
+// hand samples off to the flow-export pipeline.
+type Sink interface {
+	EmitSample(hash uint32, samples []PacketDescriptor)
+}
+
+// ReservoirConfig configures a ReservoirContext.
+type ReservoirConfig struct {
+	Size         int // number of packet descriptors kept per flow
+	TTL          time.Duration
+	EmitInterval time.Duration
+	Sink         Sink
+}
+
+func (c *ReservoirConfig) setDefaults() {
+	if c.Size <= 0 {
+		c.Size = 16
+	}
+	if c.TTL <= 0 {
+		c.TTL = 30 * time.Second
+	}
+	if c.EmitInterval <= 0 {
+		c.EmitInterval = 10 * time.Second
+	}
+}
+
+type reservoirEntry struct {
+	n        uint64 // packets seen since the last emit
+	samples  []PacketDescriptor
+	lastSeen time.Time
+}
+
+type reservoirShard struct {
+	mu      sync.Mutex
+	entries map[uint32]*reservoirEntry
+}
+
+// ReservoirContext forwards every packet it sees while keeping a bounded,
+// uniformly-random sample of each flow's packets (Algorithm R), emitted to
+// a Sink on a timer.
+type ReservoirContext struct {
+	cfg    ReservoirConfig
+	shards [shardCount]*reservoirShard
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReservoir creates a ReservoirContext with the given configuration and
+// starts its background emit loop.
+func NewReservoir(cfg ReservoirConfig) *ReservoirContext {
+	cfg.setDefaults()
+
+	rc := &ReservoirContext{cfg: cfg, stop: make(chan struct{})}
+	for i := range rc.shards {
+		rc.shards[i] = &reservoirShard{entries: make(map[uint32]*reservoirEntry)}
+	}
+
+	rc.wg.Add(1)
+	go rc.emitLoop()
+
+	return rc
+}
+
+// Sample adds the packet to hash's reservoir using Algorithm R and always
+// forwards the packet.
+func (rc *ReservoirContext) Sample(hash uint32, length int, now time.Time) Action {
+	shard := rc.shards[shardFor(hash)]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	e, found := shard.entries[hash]
+	if !found {
+		e = &reservoirEntry{samples: make([]PacketDescriptor, 0, rc.cfg.Size)}
+		shard.entries[hash] = e
+	}
+	e.lastSeen = now
+	e.n++
+
+	desc := PacketDescriptor{Hash: hash, Length: length, Timestamp: now}
+	switch {
+	case len(e.samples) < rc.cfg.Size:
+		e.samples = append(e.samples, desc)
+	default:
+		if j := rand.Int63n(int64(e.n)); j < int64(rc.cfg.Size) {
+			e.samples[j] = desc
+		}
+	}
+
+	return Forward
+}
+
+// Expire drops any per-flow reservoir that has not been touched within the
+// configured TTL.
+func (rc *ReservoirContext) Expire(now time.Time) {
+	for _, shard := range rc.shards {
+		shard.mu.Lock()
+		for hash, e := range shard.entries {
+			if now.Sub(e.lastSeen) >= rc.cfg.TTL {
+				delete(shard.entries, hash)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// Len reports the total number of live per-flow reservoirs across all
+// shards.
+func (rc *ReservoirContext) Len() int {
+	n := 0
+	for _, shard := range rc.shards {
+		shard.mu.Lock()
+		n += len(shard.entries)
+		shard.mu.Unlock()
+	}
+
+	return n
+}
+
+// Stop halts the background emit loop.
+func (rc *ReservoirContext) Stop() {
+	close(rc.stop)
+	rc.wg.Wait()
+}
+
+// emitLoop periodically flushes every flow's reservoir to the configured
+// Sink, then resets it so the next window starts counting from zero.
+func (rc *ReservoirContext) emitLoop() {
+	defer rc.wg.Done()
+
+	if rc.cfg.Sink == nil {
+		return
+	}
+
+	ticker := time.NewTicker(rc.cfg.EmitInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rc.stop:
+			return
+		case <-ticker.C:
+			rc.emit()
+		}
+	}
+}
+
+func (rc *ReservoirContext) emit() {
+	for _, shard := range rc.shards {
+		shard.mu.Lock()
+		for hash, e := range shard.entries {
+			if len(e.samples) == 0 {
+				continue
+			}
+			samples := make([]PacketDescriptor, len(e.samples))
+			copy(samples, e.samples)
+			rc.cfg.Sink.EmitSample(hash, samples)
+			e.samples = e.samples[:0]
+			e.n = 0
+		}
+		shard.mu.Unlock()
+	}
+}
@@ -0,0 +1,45 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+// Package sampler replaces the sampling app's fixed per-hash packet counter
+// with composable per-flow sampling policies: a token-bucket rate limiter
+// and a fixed-size reservoir sampler. Both strategies shard their per-flow
+// state by hash to avoid the lock contention of a single global map, and
+// expire idle flows after a configurable TTL instead of growing forever.
+package sampler
+
+import "time"
+
+// Action mirrors the "FORWARD"/"DROP" strings the sampling app already
+// renders, so existing callers and log lines do not need to change.
+type Action string
+
+const (
+	Forward Action = "FORWARD"
+	Drop    Action = "DROP"
+)
+
+// Context is the interface SamplingInfo.samplingCtx is defined in terms of.
+// It replaces the previous map[uint32]uint32 counter with a pluggable
+// sampling strategy.
+type Context interface {
+	// Sample records one packet observation for hash and returns whether it
+	// should be forwarded or dropped.
+	Sample(hash uint32, length int, now time.Time) Action
+
+	// Expire walks the per-flow state and drops entries idle longer than
+	// the configured TTL, bounding memory use.
+	Expire(now time.Time)
+
+	// Len reports the number of live per-flow entries, for displayStats.
+	Len() int
+}
+
+// shardCount is the number of shards per Context. Picking a power of two
+// keeps the "hash % shardCount" index cheap.
+const shardCount = 32
+
+func shardFor(hash uint32) int {
+	return int(hash % shardCount)
+}
@@ -0,0 +1,132 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package sampler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurstBoundary(t *testing.T) {
+	tb := NewTokenBucket(TokenBucketConfig{Rate: 10, Burst: 3})
+
+	now := time.Unix(0, 0)
+	for i := 0; i < 3; i++ {
+		if got := tb.Sample(1, 1, now); got != Forward {
+			t.Fatalf("packet %d = %s, want Forward within burst", i, got)
+		}
+	}
+
+	if got := tb.Sample(1, 1, now); got != Drop {
+		t.Fatalf("packet beyond burst = %s, want Drop", got)
+	}
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	tb := NewTokenBucket(TokenBucketConfig{Rate: 10, Burst: 1})
+
+	now := time.Unix(0, 0)
+	if got := tb.Sample(1, 1, now); got != Forward {
+		t.Fatalf("first packet = %s, want Forward", got)
+	}
+	if got := tb.Sample(1, 1, now); got != Drop {
+		t.Fatalf("second packet with no elapsed time = %s, want Drop", got)
+	}
+
+	// At 10 tokens/sec, 100ms refills exactly one token.
+	later := now.Add(100 * time.Millisecond)
+	if got := tb.Sample(1, 1, later); got != Forward {
+		t.Fatalf("packet after refill = %s, want Forward", got)
+	}
+}
+
+func TestTokenBucketExpire(t *testing.T) {
+	tb := NewTokenBucket(TokenBucketConfig{Rate: 10, Burst: 10, TTL: time.Second})
+
+	now := time.Unix(0, 0)
+	tb.Sample(1, 1, now)
+	if tb.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1 before expiry", tb.Len())
+	}
+
+	tb.Expire(now.Add(2 * time.Second))
+	if tb.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0 after expiry", tb.Len())
+	}
+}
+
+// TestReservoirSizeBound verifies Algorithm R never grows a flow's sample
+// set past the configured size, regardless of how many packets it sees.
+func TestReservoirSizeBound(t *testing.T) {
+	rc := NewReservoir(ReservoirConfig{Size: 4})
+	defer rc.Stop()
+
+	now := time.Unix(0, 0)
+	for i := 0; i < 1000; i++ {
+		rc.Sample(1, i, now)
+	}
+
+	shard := rc.shards[shardFor(1)]
+	shard.mu.Lock()
+	n := len(shard.entries[1].samples)
+	shard.mu.Unlock()
+
+	if n != 4 {
+		t.Fatalf("reservoir size = %d, want 4", n)
+	}
+}
+
+// TestReservoirUniformDistribution checks Algorithm R ends up keeping each
+// of a flow's packets, from the first to the last, with roughly equal
+// probability, rather than e.g. always favoring the first or last few.
+func TestReservoirUniformDistribution(t *testing.T) {
+	const (
+		size    = 2
+		packets = 200
+		trials  = 2000
+	)
+
+	counts := make([]int, packets)
+	now := time.Unix(0, 0)
+
+	for trial := 0; trial < trials; trial++ {
+		rc := NewReservoir(ReservoirConfig{Size: size})
+		for i := 0; i < packets; i++ {
+			rc.Sample(1, i, now)
+		}
+
+		shard := rc.shards[shardFor(1)]
+		shard.mu.Lock()
+		for _, s := range shard.entries[1].samples {
+			counts[s.Length]++
+		}
+		shard.mu.Unlock()
+		rc.Stop()
+	}
+
+	// Expected selections per packet index across all trials.
+	want := float64(trials*size) / float64(packets)
+	for i, c := range counts {
+		if float64(c) < want*0.15 || float64(c) > want*3 {
+			t.Fatalf("packet %d selected %d times across %d trials, want roughly %.1f (non-uniform sampling)", i, c, trials, want)
+		}
+	}
+}
+
+func TestReservoirExpire(t *testing.T) {
+	rc := NewReservoir(ReservoirConfig{Size: 4, TTL: time.Second})
+	defer rc.Stop()
+
+	now := time.Unix(0, 0)
+	rc.Sample(1, 1, now)
+	if rc.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1 before expiry", rc.Len())
+	}
+
+	rc.Expire(now.Add(2 * time.Second))
+	if rc.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0 after expiry", rc.Len())
+	}
+}
@@ -0,0 +1,118 @@
+/* SPDX-License-Identifier: BSD-3-Clause
+ * Copyright (c) 2017-2023 Intel Corporation.
+ */
+
+package sampler
+
+import (
+	"sync"
+	"time"
+)
+
+// TokenBucketConfig configures a TokenBucketContext.
+type TokenBucketConfig struct {
+	Rate  float64 // tokens added per second
+	Burst float64 // maximum tokens a flow can accumulate
+	TTL   time.Duration
+}
+
+func (c *TokenBucketConfig) setDefaults() {
+	if c.Rate <= 0 {
+		c.Rate = 1000
+	}
+	if c.Burst <= 0 {
+		c.Burst = c.Rate
+	}
+	if c.TTL <= 0 {
+		c.TTL = 30 * time.Second
+	}
+}
+
+type tokenBucketEntry struct {
+	tokens     float64
+	lastRefill time.Time
+	lastSeen   time.Time
+}
+
+type tokenBucketShard struct {
+	mu      sync.Mutex
+	entries map[uint32]*tokenBucketEntry
+}
+
+// TokenBucketContext forwards packets for a flow while it has tokens
+// available and drops them once the bucket is empty, refilling at Rate
+// tokens/sec up to a maximum of Burst tokens.
+type TokenBucketContext struct {
+	cfg    TokenBucketConfig
+	shards [shardCount]*tokenBucketShard
+}
+
+// NewTokenBucket creates a TokenBucketContext with the given configuration.
+func NewTokenBucket(cfg TokenBucketConfig) *TokenBucketContext {
+	cfg.setDefaults()
+
+	tb := &TokenBucketContext{cfg: cfg}
+	for i := range tb.shards {
+		tb.shards[i] = &tokenBucketShard{entries: make(map[uint32]*tokenBucketEntry)}
+	}
+
+	return tb
+}
+
+// Sample refills the bucket for hash up to the configured burst, then
+// forwards and consumes a token if at least one is available.
+func (tb *TokenBucketContext) Sample(hash uint32, _ int, now time.Time) Action {
+	shard := tb.shards[shardFor(hash)]
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	e, found := shard.entries[hash]
+	if !found {
+		e = &tokenBucketEntry{tokens: tb.cfg.Burst, lastRefill: now}
+		shard.entries[hash] = e
+	} else {
+		elapsed := now.Sub(e.lastRefill).Seconds()
+		if elapsed > 0 {
+			e.tokens += elapsed * tb.cfg.Rate
+			if e.tokens > tb.cfg.Burst {
+				e.tokens = tb.cfg.Burst
+			}
+			e.lastRefill = now
+		}
+	}
+	e.lastSeen = now
+
+	if e.tokens < 1 {
+		return Drop
+	}
+	e.tokens--
+
+	return Forward
+}
+
+// Expire drops any per-flow bucket that has not been touched within the
+// configured TTL.
+func (tb *TokenBucketContext) Expire(now time.Time) {
+	for _, shard := range tb.shards {
+		shard.mu.Lock()
+		for hash, e := range shard.entries {
+			if now.Sub(e.lastSeen) >= tb.cfg.TTL {
+				delete(shard.entries, hash)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// Len reports the total number of live per-flow buckets across all shards.
+func (tb *TokenBucketContext) Len() int {
+	n := 0
+	for _, shard := range tb.shards {
+		shard.mu.Lock()
+		n += len(shard.entries)
+		shard.mu.Unlock()
+	}
+
+	return n
+}